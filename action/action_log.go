@@ -24,12 +24,15 @@ func BuildDollarParam(i int) string {
 }
 func GetBuild(db *sql.DB) func(i int) string {
 	driver := reflect.TypeOf(db.Driver()).String()
+	if d, ok := db.Driver().(interface{ Dialect() string }); ok {
+		driver = d.Dialect()
+	}
 	switch driver {
-	case "*pq.Driver":
+	case "*pq.Driver", "postgres":
 		return BuildDollarParam
-	case "*godror.drv":
+	case "*godror.drv", "oracle":
 		return BuildOracleParam
-	case "*mssql.Driver":
+	case "*mssql.Driver", "mssql":
 		return BuildMsSqlParam
 	default:
 		return BuildParam
@@ -14,9 +14,10 @@ import (
 
 type Adapter[T any, K any] struct {
 	*Writer[*T]
-	Map    map[string]int
-	Fields string
-	IdMap  bool
+	Map       map[string]int
+	Fields    string
+	IdMap     bool
+	Relations []string
 }
 
 func NewAdapter[T any, K any](db *sql.DB, tableName string, opts ...func(int) string) (*Adapter[T, K], error) {
@@ -62,7 +63,7 @@ func NewAdapterWithVersionAndArray[T any, K any](db *sql.DB, tableName string, v
 		return nil, err
 	}
 	fields := q.BuildFieldsBySchema(adapter.Schema)
-	return &Adapter[T, K]{adapter, fieldsIndex, fields, idMap}, nil
+	return &Adapter[T, K]{Writer: adapter, Map: fieldsIndex, Fields: fields, IdMap: idMap}, nil
 }
 
 func (a *Adapter[T, K]) All(ctx context.Context) ([]T, error) {
@@ -70,8 +71,25 @@ func (a *Adapter[T, K]) All(ctx context.Context) ([]T, error) {
 	query := fmt.Sprintf("select %s from %s", a.Fields, a.Table)
 	tx := q.GetExec(ctx, a.DB, a.TxKey)
 	err := q.Query(ctx, tx, a.Map, &objs, query)
+	if err == nil && len(a.Relations) > 0 {
+		err = q.PreloadWithArray[T](ctx, tx, a.Driver, a.BuildParam, a.ToArray, objs, a.Relations...)
+	}
 	return objs, err
 }
+
+// Preload returns a copy of the adapter, which loads the relations of the objects in All, Load and Search.
+func (a *Adapter[T, K]) Preload(relations ...string) *Adapter[T, K] {
+	c := *a
+	c.Relations = relations
+	return &c
+}
+
+// Each scans all rows one by one, without loading them in memory.
+func (a *Adapter[T, K]) Each(ctx context.Context, fn func(*T) error) error {
+	query := fmt.Sprintf("select %s from %s", a.Fields, a.Table)
+	tx := q.GetExec(ctx, a.DB, a.TxKey)
+	return q.EachWithArray[T](ctx, tx, a.Map, a.ToArray, false, query, nil, fn)
+}
 func toMap(obj interface{}) (map[string]interface{}, error) {
 	b, err := json.Marshal(obj)
 	if err != nil {
@@ -103,6 +121,12 @@ func (a *Adapter[T, K]) Load(ctx context.Context, id K) (*T, error) {
 		return nil, err
 	}
 	if len(objs) > 0 {
+		if len(a.Relations) > 0 {
+			err = q.PreloadWithArray[T](ctx, tx, a.Driver, a.BuildParam, a.ToArray, objs[:1], a.Relations...)
+			if err != nil {
+				return nil, err
+			}
+		}
 		return &objs[0], nil
 	}
 	return nil, nil
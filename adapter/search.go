@@ -57,6 +57,9 @@ func (b *SearchAdapter[T, K, F]) Search(ctx context.Context, filter F, limit int
 	var objs []T
 	query, args := b.BuildQuery(filter)
 	total, er2 := q.BuildFromQuery(ctx, b.DB, b.Map, &objs, query, args, limit, offset, b.ToArray)
+	if er2 == nil && len(b.Relations) > 0 {
+		er2 = q.PreloadWithArray[T](ctx, q.GetExec(ctx, b.DB, b.TxKey), b.Driver, b.BuildParam, b.ToArray, objs, b.Relations...)
+	}
 	if b.Mp != nil {
 		l := len(objs)
 		for i := 0; i < l; i++ {
@@ -65,3 +68,22 @@ func (b *SearchAdapter[T, K, F]) Search(ctx context.Context, filter F, limit int
 	}
 	return objs, total, er2
 }
+
+// Preload returns a copy of the search adapter, which loads the relations of the objects in All, Load and Search.
+func (b *SearchAdapter[T, K, F]) Preload(relations ...string) *SearchAdapter[T, K, F] {
+	c := *b
+	c.Adapter = b.Adapter.Preload(relations...)
+	return &c
+}
+
+// SearchEach scans the rows of the filter one by one, without paging and without loading them in memory.
+func (b *SearchAdapter[T, K, F]) SearchEach(ctx context.Context, filter F, fn func(*T) error) error {
+	query, args := b.BuildQuery(filter)
+	tx := q.GetExec(ctx, b.DB, b.TxKey)
+	return q.EachWithArray[T](ctx, tx, b.Map, b.ToArray, false, query, args, func(obj *T) error {
+		if b.Mp != nil {
+			b.Mp(obj)
+		}
+		return fn(obj)
+	})
+}
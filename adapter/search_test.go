@@ -0,0 +1,49 @@
+package adapter
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/core-go/sql/mock"
+)
+
+type item struct {
+	Id   string `gorm:"column:id;primary_key"`
+	Name string `gorm:"column:name"`
+}
+type order struct {
+	Id    string `json:"id" gorm:"column:id;primary_key"`
+	Items []item `relation:"has_many;table:order_items;foreign_key:order_id"`
+}
+type orderFilter struct{}
+
+func TestSearchPreloadsInTransaction(t *testing.T) {
+	db, rec := mock.New("postgres")
+	rec.ExpectRegexp("^select id from orders").WillReturnRows([]string{"id"}, []interface{}{"1"})
+	rec.ExpectRegexp("from order_items").WillReturnRows([]string{"order_id", "id", "name"}, []interface{}{"1", "a", "A"})
+	s, err := NewSearchAdapter[order, string, orderFilter](db, "orders", func(orderFilter) (string, []interface{}) {
+		return "select id from orders", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	ctx := context.WithValue(context.Background(), "tx", tx)
+	orders, _, err := s.Preload("Items").Search(ctx, orderFilter{}, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orders) != 1 || len(orders[0].Items) != 1 {
+		t.Fatalf("expected the order with its item, got %v", orders)
+	}
+	for _, st := range rec.Statements() {
+		if strings.Contains(st.Query, "order_items") && !st.Tx {
+			t.Errorf("expected the children to be read in the transaction: %s", st.Query)
+		}
+	}
+}
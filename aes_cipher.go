@@ -0,0 +1,81 @@
+package sql
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// AESCipher is the AES-GCM cipher. The ciphertext is "keyId:base64(nonce + sealed)", so the old keys can still decrypt after the rotation.
+// In the deterministic mode, the nonce is the HMAC-SHA256 of the plaintext, so the equality only matches the values encrypted by the current key.
+type AESCipher struct {
+	KeyId string
+	aeads map[string]cipher.AEAD
+	macs  map[string][]byte
+}
+
+// NewAESCipher creates the cipher, which encrypts by the key of keyId, and decrypts by any key of keys. The keys must be 16, 24 or 32 bytes.
+func NewAESCipher(keyId string, keys map[string][]byte) (*AESCipher, error) {
+	if _, ok := keys[keyId]; !ok {
+		return nil, fmt.Errorf("key '%s' does not exist", keyId)
+	}
+	c := &AESCipher{KeyId: keyId, aeads: make(map[string]cipher.AEAD), macs: make(map[string][]byte)}
+	for id, key := range keys {
+		if len(id) == 0 || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("invalid key id '%s'", id)
+		}
+		block, er1 := aes.NewCipher(key)
+		if er1 != nil {
+			return nil, er1
+		}
+		aead, er2 := cipher.NewGCM(block)
+		if er2 != nil {
+			return nil, er2
+		}
+		c.aeads[id] = aead
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte("nonce"))
+		c.macs[id] = mac.Sum(nil)
+	}
+	return c, nil
+}
+
+func (c *AESCipher) Encrypt(plaintext []byte, deterministic bool) (string, error) {
+	aead := c.aeads[c.KeyId]
+	nonce := make([]byte, aead.NonceSize())
+	if deterministic {
+		mac := hmac.New(sha256.New, c.macs[c.KeyId])
+		mac.Write(plaintext)
+		copy(nonce, mac.Sum(nil))
+	} else if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, []byte(c.KeyId))
+	return c.KeyId + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+func (c *AESCipher) Decrypt(ciphertext string) ([]byte, error) {
+	i := strings.Index(ciphertext, ":")
+	if i < 0 {
+		return nil, errors.New("invalid ciphertext")
+	}
+	keyId := ciphertext[:i]
+	aead, ok := c.aeads[keyId]
+	if !ok {
+		return nil, fmt.Errorf("key '%s' does not exist", keyId)
+	}
+	sealed, er1 := base64.StdEncoding.DecodeString(ciphertext[i+1:])
+	if er1 != nil {
+		return nil, er1
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("invalid ciphertext")
+	}
+	return aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(keyId))
+}
@@ -159,6 +159,9 @@ func BuildToInsertBatchWithSchema(table string, models interface{}, driver strin
 							fieldValue = reflect.Indirect(reflect.ValueOf(fieldValue)).Interface()
 						}
 					}
+					if !isNil {
+						fieldValue, isNil = ToDBValueOrNull(fdb, fieldValue)
+					}
 					if isNil {
 						values = append(values, "null")
 					} else {
@@ -225,6 +228,9 @@ func BuildToInsertBatchWithSchema(table string, models interface{}, driver strin
 							fieldValue = reflect.Indirect(reflect.ValueOf(fieldValue)).Interface()
 						}
 					}
+					if !isNil {
+						fieldValue, isNil = ToDBValueOrNull(fdb, fieldValue)
+					}
 					if !isNil {
 						iCols = append(iCols, fdb.Column)
 						v, ok := GetDBValue(fieldValue, false, fdb.Scale)
@@ -22,6 +22,10 @@ type BatchInserter[T any] struct {
 		driver.Valuer
 		sql.Scanner
 	}
+	// SkipDuplicates skips the rows which violate the primary key or a unique key, instead of failing the whole batch
+	SkipDuplicates bool
+	// Fallback is FallbackBisect or FallbackRow to write the rows of a failed batch again, by WriteWithResult
+	Fallback string
 }
 
 func NewBatchInserter[T any](db *sql.DB, tableName string, options ...func(*T)) *BatchInserter[T] {
@@ -58,33 +62,73 @@ func NewSqlBatchInserter[T any](db *sql.DB, tableName string, mp func(*T), toArr
 	}
 	driver := q.GetDriver(db)
 	boolSupport := driver == q.DriverPostgres
-	schema := q.CreateSchema(modelType)
+	schema := q.CreateSchema(modelType, driver)
 	return &BatchInserter[T]{db: db, tableName: tableName, BuildParam: buildParam, BoolSupport: boolSupport, Schema: schema, Driver: driver, Map: mp, ToArray: toArray}
 }
 
 func (w *BatchInserter[T]) Write(ctx context.Context, models []T) error {
+	_, _, err := w.WriteAndCount(ctx, models)
+	return err
+}
+
+// WriteAndCount returns the number of the inserted rows and the number of the skipped rows. The rows are skipped only if SkipDuplicates is true.
+func (w *BatchInserter[T]) WriteAndCount(ctx context.Context, models []T) (int64, int64, error) {
 	l := len(models)
 	if l == 0 {
-		return nil
+		return 0, 0, nil
 	}
 	if w.Map != nil {
 		for i := 0; i < l; i++ {
 			w.Map(&models[i])
 		}
 	}
-	query, args, err := q.BuildToInsertBatchWithSchema(w.tableName, models, w.Driver, w.ToArray, w.BuildParam, w.Schema)
+	res, err := w.exec(ctx, models)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !w.SkipDuplicates {
+		return int64(l), 0, nil
+	}
+	inserted, err := res.RowsAffected()
 	if err != nil {
+		return 0, 0, err
+	}
+	return inserted, int64(l) - inserted, nil
+}
+
+// WriteWithResult writes the models, and writes the rows of a failed batch again by Fallback. It returns the error of the batch only if Fallback is empty.
+func (w *BatchInserter[T]) WriteWithResult(ctx context.Context, models []T) (Result, error) {
+	if w.Map != nil {
+		for i := range models {
+			w.Map(&models[i])
+		}
+	}
+	return writeWithFallback(ctx, models, w.Fallback, func(ctx context.Context, models []T) error {
+		_, err := w.exec(ctx, models)
 		return err
+	})
+}
+func (w *BatchInserter[T]) exec(ctx context.Context, models []T) (sql.Result, error) {
+	var query string
+	var args []interface{}
+	var err error
+	if w.SkipDuplicates {
+		query, args, err = q.BuildToInsertIgnoreBatch(w.tableName, models, w.Driver, w.ToArray, w.BuildParam, w.Schema)
+	} else {
+		query, args, err = q.BuildToInsertBatchWithSchema(w.tableName, models, w.Driver, w.ToArray, w.BuildParam, w.Schema)
+	}
+	if err != nil {
+		return nil, err
 	}
 	tx, err := w.db.BeginTx(ctx, nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer tx.Rollback()
 
-	_, err = tx.ExecContext(ctx, query, args...)
+	res, err := tx.ExecContext(ctx, query, args...)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return tx.Commit()
+	return res, tx.Commit()
 }
@@ -16,6 +16,8 @@ type BatchPatcher struct {
 	buildParam  func(i int) string
 	modelsType  reflect.Type
 	modelsTypes reflect.Type
+	// Fallback is FallbackBisect or FallbackRow to write the rows of a failed batch again, by WriteWithResult
+	Fallback string
 }
 
 func NewBatchPatcher(db *sql.DB, tableName string, modelType reflect.Type, options ...func(i int) string) *BatchPatcher {
@@ -52,6 +54,14 @@ func (w *BatchPatcher) Write(ctx context.Context, models []map[string]interface{
 	return successIndices, failIndices, err
 }
 
+// WriteWithResult patches the models, and patches the rows of a failed batch again by Fallback. It returns the error of the batch only if Fallback is empty.
+func (w *BatchPatcher) WriteWithResult(ctx context.Context, models []map[string]interface{}) (Result, error) {
+	return writeWithFallback(ctx, models, w.Fallback, func(ctx context.Context, models []map[string]interface{}) error {
+		_, err := q.PatchInTransaction(ctx, w.db, w.tableName, models, w.idNames, w.idJsonName, w.buildParam)
+		return err
+	})
+}
+
 func toArrayMapIndex(models []map[string]interface{}, indices []int) []int {
 	for i, _ := range models {
 		indices = append(indices, i)
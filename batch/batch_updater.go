@@ -22,6 +22,8 @@ type BatchUpdater[T any] struct {
 		driver.Valuer
 		sql.Scanner
 	}
+	// Fallback is FallbackBisect or FallbackRow to write the rows of a failed batch again, by WriteWithResult
+	Fallback string
 }
 
 func NewBatchUpdater[T any](db *sql.DB, tableName string, options ...func(*T)) *BatchUpdater[T] {
@@ -68,7 +70,7 @@ func NewSqlBatchUpdater[T any](db *sql.DB, tableName string, versionIndex int, m
 	}
 	driver := q.GetDriver(db)
 	boolSupport := driver == q.DriverPostgres
-	schema := q.CreateSchema(modelType)
+	schema := q.CreateSchema(modelType, driver)
 	if len(schema.Keys) <= 0 {
 		panic(fmt.Sprintf("require primary key for table '%s'", tableName))
 	}
@@ -84,6 +86,19 @@ func (w *BatchUpdater[T]) Write(ctx context.Context, models []T) error {
 			w.Map(&models[i])
 		}
 	}
+	return w.exec(ctx, models)
+}
+
+// WriteWithResult writes the models, and writes the rows of a failed batch again by Fallback. It returns the error of the batch only if Fallback is empty.
+func (w *BatchUpdater[T]) WriteWithResult(ctx context.Context, models []T) (Result, error) {
+	if w.Map != nil {
+		for i := range models {
+			w.Map(&models[i])
+		}
+	}
+	return writeWithFallback(ctx, models, w.Fallback, w.exec)
+}
+func (w *BatchUpdater[T]) exec(ctx context.Context, models []T) error {
 	var queryArgsArray []q.Statement
 	for _, v := range models {
 		query, args := q.BuildToUpdateWithArray(w.tableName, v, w.BuildParam, w.BoolSupport, w.ToArray, w.Schema)
@@ -20,6 +20,8 @@ type BatchWriter[T any] struct {
 		driver.Valuer
 		sql.Scanner
 	}
+	// Fallback is FallbackBisect or FallbackRow to write the rows of a failed batch again, by WriteWithResult
+	Fallback string
 }
 
 func NewBatchWriter[T any](db *sql.DB, tableName string, options ...func(*T)) *BatchWriter[T] {
@@ -43,7 +45,7 @@ func NewBatchWriterWithArray[T any](db *sql.DB, tableName string, toArray func(i
 	if len(options) > 0 && options[0] != nil {
 		mp = options[0]
 	}
-	schema := q.CreateSchema(modelType)
+	schema := q.CreateSchema(modelType, driver)
 	if len(schema.Keys) <= 0 {
 		panic(fmt.Sprintf("require primary key for table '%s'", tableName))
 	}
@@ -60,6 +62,19 @@ func (w *BatchWriter[T]) Write(ctx context.Context, models []T) error {
 			w.Map(&models[i])
 		}
 	}
+	return w.exec(ctx, models)
+}
+
+// WriteWithResult writes the models, and writes the rows of a failed batch again by Fallback. It returns the error of the batch only if Fallback is empty.
+func (w *BatchWriter[T]) WriteWithResult(ctx context.Context, models []T) (Result, error) {
+	if w.Map != nil {
+		for i := range models {
+			w.Map(&models[i])
+		}
+	}
+	return writeWithFallback(ctx, models, w.Fallback, w.exec)
+}
+func (w *BatchWriter[T]) exec(ctx context.Context, models []T) error {
 	var queryArgsArray []q.Statement
 	for _, v := range models {
 		query, args, err := q.BuildToSaveWithArray(w.tableName, v, w.Driver, w.ToArray, w.Schema)
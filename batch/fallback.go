@@ -0,0 +1,102 @@
+package batch
+
+import (
+	"context"
+	"fmt"
+
+	q "github.com/core-go/sql"
+)
+
+const (
+	// FallbackNone fails the whole batch if a row fails. It is the default.
+	FallbackNone = ""
+	// FallbackBisect splits the failed batch into halves, and writes each half again, until the failed rows are found.
+	FallbackBisect = "bisect"
+	// FallbackRow writes the rows of the failed batch one by one.
+	FallbackRow = "row"
+)
+
+// Result is the result of WriteWithResult: the indices of the successful rows and of the failed rows, and the error of each failed row.
+type Result struct {
+	Success []int
+	Fail    []int
+	Errors  []RowError
+}
+
+// RowError is the error of a row. Type is q.ErrorDuplicate, q.ErrorConstraint, q.ErrorData or q.ErrorOther.
+type RowError struct {
+	Index int
+	Type  string
+	Err   error
+}
+
+func (e RowError) Error() string {
+	return fmt.Sprintf("row %d: %s", e.Index, e.Err.Error())
+}
+func (e RowError) Unwrap() error {
+	return e.Err
+}
+
+func (r *Result) fail(indices []int, err error) {
+	t := q.ClassifyError(err)
+	for _, i := range indices {
+		r.Fail = append(r.Fail, i)
+		r.Errors = append(r.Errors, RowError{Index: i, Type: t, Err: err})
+	}
+}
+
+// writeWithFallback writes the models by write, which must be all or nothing. If it fails, the models are written again by the fallback.
+// Without fallback, the error of the batch is returned, and all the rows are failed.
+func writeWithFallback[T any](ctx context.Context, models []T, fallback string, write func(context.Context, []T) error) (Result, error) {
+	res := Result{Success: make([]int, 0), Fail: make([]int, 0), Errors: make([]RowError, 0)}
+	indices := make([]int, len(models))
+	for i := range models {
+		indices[i] = i
+	}
+	err := write(ctx, models)
+	if err == nil {
+		res.Success = indices
+		return res, nil
+	}
+	if fallback != FallbackBisect && fallback != FallbackRow {
+		res.fail(indices, err)
+		return res, err
+	}
+	if len(models) == 1 {
+		res.fail(indices, err)
+		return res, nil
+	}
+	if fallback == FallbackRow {
+		for i := range models {
+			retry(ctx, &res, models[i:i+1], indices[i:i+1], false, write)
+		}
+	} else {
+		half := len(models) / 2
+		retry(ctx, &res, models[:half], indices[:half], true, write)
+		retry(ctx, &res, models[half:], indices[half:], true, write)
+	}
+	return res, nil
+}
+
+// retry writes the models again. If they fail and bisect is true, they are split into halves until the failed rows are found.
+func retry[T any](ctx context.Context, res *Result, models []T, indices []int, bisect bool, write func(context.Context, []T) error) {
+	if len(models) == 0 {
+		return
+	}
+	if err := ctx.Err(); err != nil {
+		res.fail(indices, err)
+		return
+	}
+	err := write(ctx, models)
+	if err == nil {
+		res.Success = append(res.Success, indices...)
+		return
+	}
+	if !bisect || len(models) == 1 {
+		res.fail(indices, err)
+		return
+	}
+	half := len(models) / 2
+	retry(ctx, res, models[:half], indices[:half], true, write)
+	retry(ctx, res, models[half:], indices[half:], true, write)
+}
@@ -0,0 +1,117 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	q "github.com/core-go/sql"
+	"github.com/core-go/sql/mock"
+)
+
+type testRow struct {
+	Id    string `gorm:"column:id;primary_key"`
+	Count int64  `gorm:"column:count"`
+}
+
+// testStateError is the error of a driver with SQLSTATE, such as pgx
+type testStateError struct {
+	state string
+}
+
+func (e testStateError) Error() string {
+	return "state " + e.state
+}
+func (e testStateError) SQLState() string {
+	return e.state
+}
+
+func newRows() []testRow {
+	return []testRow{{"0", 1}, {"1", 1}, {"2", 1}, {"3", 1}, {"4", 99}, {"5", 1}}
+}
+func countInserts(rec *mock.Recorder) int {
+	n := 0
+	for _, s := range rec.Statements() {
+		if strings.HasPrefix(s.Query, "insert") {
+			n++
+		}
+	}
+	return n
+}
+
+func TestWriteWithResultBisect(t *testing.T) {
+	db, rec := mock.New("postgres")
+	rec.ExpectRegexp(`,99\)`).Times(0).WillReturnError(testStateError{"23505"})
+	w := NewBatchInserter[testRow](db, "rows")
+	w.Fallback = FallbackBisect
+	res, err := w.WriteWithResult(context.Background(), newRows())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(res.Success, []int{0, 1, 2, 3, 5}) || !reflect.DeepEqual(res.Fail, []int{4}) {
+		t.Fatalf("expected the row 4 to fail, got %v %v", res.Success, res.Fail)
+	}
+	if len(res.Errors) != 1 || res.Errors[0].Index != 4 || res.Errors[0].Type != q.ErrorDuplicate {
+		t.Fatalf("expected the duplicate error of row 4, got %v", res.Errors)
+	}
+	var stateErr testStateError
+	if !errors.As(res.Errors[0], &stateErr) {
+		t.Error("expected the error of the driver to be unwrapped")
+	}
+	// the batch of 6 rows, the halves of 3 rows, then 1 row and 2 rows of the failed half, then 1 row and 1 row
+	if n := countInserts(rec); n != 7 {
+		t.Errorf("expected 7 inserts, got %d", n)
+	}
+}
+
+func TestWriteWithResultRow(t *testing.T) {
+	db, rec := mock.New("mysql")
+	rec.ExpectRegexp(`,99\)`).Times(0).WillReturnError(errors.New("Data too long for column"))
+	w := NewBatchInserter[testRow](db, "rows")
+	w.Fallback = FallbackRow
+	res, err := w.WriteWithResult(context.Background(), newRows())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Success) != 5 || !reflect.DeepEqual(res.Fail, []int{4}) || res.Errors[0].Type != q.ErrorData {
+		t.Fatalf("expected the data error of row 4, got %v %v %v", res.Success, res.Fail, res.Errors)
+	}
+	if n := countInserts(rec); n != 7 {
+		t.Errorf("expected the batch and 6 rows, got %d inserts", n)
+	}
+}
+
+func TestWriteWithResultWithoutFallback(t *testing.T) {
+	db, rec := mock.New("postgres")
+	failed := testStateError{"23503"}
+	rec.ExpectRegexp(`,99\)`).Times(0).WillReturnError(failed)
+	w := NewBatchInserter[testRow](db, "rows")
+	res, err := w.WriteWithResult(context.Background(), newRows())
+	if err != failed {
+		t.Fatalf("expected the error of the batch, got %v", err)
+	}
+	if len(res.Success) != 0 || len(res.Fail) != 6 || res.Errors[0].Type != q.ErrorConstraint {
+		t.Fatalf("expected all rows to fail, got %v %v %v", res.Success, res.Fail, res.Errors)
+	}
+	if n := countInserts(rec); n != 1 {
+		t.Errorf("expected 1 insert, got %d", n)
+	}
+	statements := rec.Statements()
+	if last := statements[len(statements)-1]; last.Query != mock.Rollback {
+		t.Errorf("expected the batch to be rolled back, got %v", last)
+	}
+}
+
+func TestWriteWithResultCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	rows := newRows()
+	res, err := writeWithFallback(ctx, rows, FallbackBisect, func(ctx context.Context, models []testRow) error {
+		cancel()
+		return errors.New("failed")
+	})
+	if err != nil || len(res.Fail) != 6 || res.Errors[5].Err != context.Canceled {
+		t.Fatalf("expected all rows to fail by the canceled context, got %v %v", res, err)
+	}
+}
@@ -62,6 +62,9 @@ func BuildToInsertWithSchema(table string, model interface{}, versionIndex int,
 					fieldValue = reflect.Indirect(reflect.ValueOf(fieldValue)).Interface()
 				}
 			}
+			if !isNil {
+				fieldValue, isNil = ToDBValueOrNull(fdb, fieldValue)
+			}
 			if fdb.Insert {
 				if isNil {
 					if includeNull {
@@ -164,6 +167,9 @@ func BuildToUpdateWithVersion(table string, model interface{}, versionIndex int,
 					fieldValue = reflect.Indirect(reflect.ValueOf(fieldValue)).Interface()
 				}
 			}
+			if !isNil {
+				fieldValue, isNil = ToDBValueOrNull(fdb, fieldValue)
+			}
 			if isNil {
 				values = append(values, fdb.Column+"=null")
 			} else {
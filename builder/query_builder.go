@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	s "github.com/core-go/search"
+	q "github.com/core-go/sql"
 	"reflect"
 	"strconv"
 	"strings"
@@ -197,6 +198,20 @@ func Build(fm interface{}, tableName string, modelType reflect.Type, driver stri
 			}
 			continue
 		}
+		if key, ok := tag.Lookup("operator"); ok && key == "null" {
+			if isNull, ok := q.GetNullableBool(x); ok {
+				if isNull {
+					rawConditions = append(rawConditions, columnName+" is null")
+				} else {
+					rawConditions = append(rawConditions, columnName+" is not null")
+				}
+			}
+			continue
+		}
+		nv, null := q.GetNullValue(x)
+		if null {
+			continue
+		}
 		if v, ok := x.(*s.Filter); ok {
 			if v.Excluding != nil && len(v.Excluding) > 0 {
 				index, _, columnName := getFieldByBson(value.Type(), "_id")
@@ -215,8 +230,14 @@ func Build(fm interface{}, tableName string, modelType reflect.Type, driver stri
 				if !ok {
 					key, _ = tag.Lookup("q")
 				}
-				if key == "=" {
+				deterministic := isDeterministic(modelType, typeOfField)
+				if key == "=" || deterministic {
 					rawConditions = append(rawConditions, fmt.Sprintf("%s %s %s", columnName, "=", param))
+					if deterministic {
+						queryValues = append(queryValues, q.Encrypt(value2, true))
+					} else {
+						queryValues = append(queryValues, value2)
+					}
 				} else {
 					if driver == driverPostgres { // "postgres"
 						rawConditions = append(rawConditions, fmt.Sprintf("%s %s %s", columnName, `ilike`, param))
@@ -405,7 +426,11 @@ func Build(fm interface{}, tableName string, modelType reflect.Type, driver stri
 			if field.Len() > 0 {
 				format := fmt.Sprintf("(%s)", buildParametersFrom(marker, field.Len(), buildParam))
 				rawConditions = append(rawConditions, fmt.Sprintf("%s %s %s", columnName, in, format))
-				queryValues = extractArray(queryValues, x)
+				if isDeterministic(modelType, typeOfField) {
+					queryValues = encryptArray(queryValues, x)
+				} else {
+					queryValues = extractArray(queryValues, x)
+				}
 				marker += field.Len()
 			}
 		} else {
@@ -413,6 +438,11 @@ func Build(fm interface{}, tableName string, modelType reflect.Type, driver stri
 			if !ok {
 				key = "="
 			}
+			if isDeterministic(modelType, typeOfField) {
+				x = q.Encrypt(x, true)
+			} else {
+				x = q.ConvertArg(nv, driver)
+			}
 			rawConditions = append(rawConditions, fmt.Sprintf("%s %s %s", columnName, key, param))
 			queryValues = append(queryValues, x)
 			marker += 1
@@ -456,6 +486,14 @@ func Build(fm interface{}, tableName string, modelType reflect.Type, driver stri
 	s3 := s1 + sortString
 	return s3, queryValues
 }
+// isDeterministic returns true if the field of the filter, or the field of the model with the same name, has the tag encrypt:"deterministic".
+func isDeterministic(modelType reflect.Type, filterField reflect.StructField) bool {
+	if filterField.Tag.Get("encrypt") == q.EncryptDeterministic {
+		return true
+	}
+	field, ok := modelType.FieldByName(filterField.Name)
+	return ok && field.Tag.Get("encrypt") == q.EncryptDeterministic
+}
 func extractArray(values []interface{}, field interface{}) []interface{} {
 	s := reflect.Indirect(reflect.ValueOf(field))
 	for i := 0; i < s.Len(); i++ {
@@ -463,6 +501,14 @@ func extractArray(values []interface{}, field interface{}) []interface{} {
 	}
 	return values
 }
+// encryptArray appends the elements of field, encrypted deterministically, to match a column with encrypt:"deterministic" by "in".
+func encryptArray(values []interface{}, field interface{}) []interface{} {
+	s := reflect.Indirect(reflect.ValueOf(field))
+	for i := 0; i < s.Len(); i++ {
+		values = append(values, q.Encrypt(s.Index(i).Interface(), true))
+	}
+	return values
+}
 func getFieldByJson(modelType reflect.Type, jsonName string) (int, string, string) {
 	numField := modelType.NumField()
 	for i := 0; i < numField; i++ {
@@ -607,16 +653,19 @@ func getDriver(db *sql.DB) string {
 		return driverNotSupport
 	}
 	driver := reflect.TypeOf(db.Driver()).String()
+	if d, ok := db.Driver().(interface{ Dialect() string }); ok {
+		driver = d.Dialect()
+	}
 	switch driver {
-	case "*pq.Driver":
+	case "*pq.Driver", "postgres":
 		return driverPostgres
-	case "*godror.drv":
+	case "*godror.drv", "oracle":
 		return driverOracle
-	case "*mysql.MySQLDriver":
+	case "*mysql.MySQLDriver", "mysql":
 		return driverMysql
-	case "*mssql.Driver":
+	case "*mssql.Driver", "mssql":
 		return driverMssql
-	case "*sqlite3.SQLiteDriver":
+	case "*sqlite3.SQLiteDriver", "sqlite3":
 		return driverSqlite3
 	default:
 		return driverNotSupport
@@ -636,12 +685,15 @@ func buildDollarParam(i int) string {
 }
 func getBuild(db *sql.DB) func(i int) string {
 	driver := reflect.TypeOf(db.Driver()).String()
+	if d, ok := db.Driver().(interface{ Dialect() string }); ok {
+		driver = d.Dialect()
+	}
 	switch driver {
-	case "*pq.Driver":
+	case "*pq.Driver", "postgres":
 		return buildDollarParam
-	case "*godror.drv":
+	case "*godror.drv", "oracle":
 		return buildOracleParam
-	case "*mssql.Driver":
+	case "*mssql.Driver", "mssql":
 		return buildMsSqlParam
 	default:
 		return buildParam
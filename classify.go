@@ -0,0 +1,160 @@
+package sql
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+)
+
+const (
+	ErrorDuplicate  = "duplicate"
+	ErrorConstraint = "constraint"
+	ErrorData       = "data"
+	ErrorOther      = "other"
+)
+
+// errorCodes are the error codes of a driver, by the field or the method of its error type.
+type errorCodes struct {
+	field      string
+	duplicate  map[int64]bool
+	constraint map[int64]bool
+	data       map[int64]bool
+}
+
+var (
+	mysqlCodes = errorCodes{
+		field:      "Number",
+		duplicate:  map[int64]bool{1062: true},
+		constraint: map[int64]bool{1451: true, 1452: true, 1048: true, 3819: true},
+		data:       map[int64]bool{1264: true, 1292: true, 1366: true, 1406: true},
+	}
+	mssqlCodes = errorCodes{
+		field:      "Number",
+		duplicate:  map[int64]bool{2627: true, 2601: true},
+		constraint: map[int64]bool{547: true, 515: true},
+		data:       map[int64]bool{245: true, 2628: true, 8114: true, 8152: true},
+	}
+	// oracleCodes are the ORA- numbers, by the field ErrCode of go-ora, or the method Code of godror
+	oracleCodes = errorCodes{
+		field:      "ErrCode",
+		duplicate:  map[int64]bool{1: true},
+		constraint: map[int64]bool{2290: true, 2291: true, 2292: true, 1400: true},
+		data:       map[int64]bool{1722: true, 1840: true, 1861: true, 12899: true},
+	}
+	// sqliteCodes are the extended result codes, by the field ExtendedCode of go-sqlite3, or the method Code of modernc.org/sqlite
+	sqliteCodes = errorCodes{
+		field:      "ExtendedCode",
+		duplicate:  map[int64]bool{1555: true, 2067: true},
+		constraint: map[int64]bool{19: true, 275: true, 787: true, 1299: true},
+		data:       map[int64]bool{20: true},
+	}
+	// errorCodesByPackage finds the codes of an error by the package of its type
+	errorCodesByPackage = []struct {
+		pkg   string
+		codes *errorCodes
+	}{
+		{"go-sql-driver/mysql", &mysqlCodes},
+		{"mssqldb", &mssqlCodes},
+		{"go-ora", &oracleCodes},
+		{"godror", &oracleCodes},
+		{"sqlite", &sqliteCodes},
+	}
+)
+
+// ClassifyError returns ErrorDuplicate, ErrorConstraint, ErrorData or ErrorOther, without importing the drivers.
+// It checks the SQLSTATE of Postgres, the error codes of My SQL, MS SQL, Oracle and SQLite by the package of the error type, then the message.
+// The codes of an unknown error type are not checked, because the same code has different meanings in the drivers, such as 1 for Oracle and SQLite.
+func ClassifyError(err error) string {
+	if err == nil {
+		return ""
+	}
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if state := getSQLState(e); len(state) == 5 {
+			if state == "23505" {
+				return ErrorDuplicate
+			}
+			if strings.HasPrefix(state, "23") {
+				return ErrorConstraint
+			}
+			if strings.HasPrefix(state, "22") {
+				return ErrorData
+			}
+			return ErrorOther
+		}
+		if codes, code, ok := getErrorCode(e); ok {
+			if codes.duplicate[code] {
+				return ErrorDuplicate
+			}
+			if codes.constraint[code] {
+				return ErrorConstraint
+			}
+			if codes.data[code] {
+				return ErrorData
+			}
+		}
+	}
+	s := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(s, "duplicate") || strings.Contains(s, "unique"):
+		return ErrorDuplicate
+	case strings.Contains(s, "constraint") || strings.Contains(s, "foreign key") || strings.Contains(s, "not null") || strings.Contains(s, "cannot be null"):
+		return ErrorConstraint
+	case strings.Contains(s, "invalid input") || strings.Contains(s, "out of range") || strings.Contains(s, "too long") || strings.Contains(s, "truncat") ||
+		strings.Contains(s, "incorrect") || strings.Contains(s, "conversion") || strings.Contains(s, "invalid number") || strings.Contains(s, "mismatch"):
+		return ErrorData
+	}
+	return ErrorOther
+}
+
+// getSQLState returns the SQLSTATE by the method SQLState, such as pgx, or by the string field Code, such as lib/pq.
+func getSQLState(err error) string {
+	if e, ok := err.(interface{ SQLState() string }); ok {
+		return e.SQLState()
+	}
+	v := reflect.Indirect(reflect.ValueOf(err))
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+	if f := v.FieldByName("Code"); f.IsValid() && f.Kind() == reflect.String {
+		return f.String()
+	}
+	return ""
+}
+
+// getErrorCode returns the codes of the driver of err, and the code of err by the field of the driver, or by the method Code.
+func getErrorCode(err error) (*errorCodes, int64, bool) {
+	v := reflect.Indirect(reflect.ValueOf(err))
+	if v.Kind() != reflect.Struct {
+		return nil, 0, false
+	}
+	var codes *errorCodes
+	pkg := v.Type().PkgPath()
+	for _, c := range errorCodesByPackage {
+		if strings.Contains(pkg, c.pkg) {
+			codes = c.codes
+			break
+		}
+	}
+	if codes == nil {
+		return nil, 0, false
+	}
+	if code, ok := toInt64(v.FieldByName(codes.field)); ok {
+		return codes, code, true
+	}
+	if e, ok := err.(interface{ Code() int }); ok {
+		return codes, int64(e.Code()), true
+	}
+	return nil, 0, false
+}
+func toInt64(f reflect.Value) (int64, bool) {
+	if !f.IsValid() {
+		return 0, false
+	}
+	switch f.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return f.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(f.Uint()), true
+	}
+	return 0, false
+}
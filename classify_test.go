@@ -0,0 +1,64 @@
+package sql
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+type testStateError struct {
+	state string
+}
+
+func (e testStateError) Error() string {
+	return "pq: error"
+}
+func (e testStateError) SQLState() string {
+	return e.state
+}
+
+// testCodeError has the string field Code, such as lib/pq
+type testCodeError struct {
+	Code    string
+	Message string
+}
+
+func (e *testCodeError) Error() string {
+	return e.Message
+}
+
+// testNumberError has the error number of an unknown driver, so only its message is classified
+type testNumberError struct {
+	Number int
+}
+
+func (e testNumberError) Error() string {
+	return fmt.Sprintf("error %d", e.Number)
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected string
+	}{
+		{nil, ""},
+		{testStateError{"23505"}, ErrorDuplicate},
+		{testStateError{"23503"}, ErrorConstraint},
+		{testStateError{"22001"}, ErrorData},
+		{testStateError{"40001"}, ErrorOther},
+		{&testCodeError{Code: "23505", Message: "x"}, ErrorDuplicate},
+		{fmt.Errorf("insert: %w", testStateError{"23502"}), ErrorConstraint},
+		{testNumberError{1062}, ErrorOther},
+		{errors.New("Duplicate entry '1' for key 'PRIMARY'"), ErrorDuplicate},
+		{errors.New("UNIQUE constraint failed: users.id"), ErrorDuplicate},
+		{errors.New("FOREIGN KEY constraint failed"), ErrorConstraint},
+		{errors.New("Column 'name' cannot be null"), ErrorConstraint},
+		{errors.New("Out of range value for column 'age'"), ErrorData},
+		{errors.New("connection refused"), ErrorOther},
+	}
+	for _, tt := range tests {
+		if c := ClassifyError(tt.err); c != tt.expected {
+			t.Errorf("%v: expected %q, got %q", tt.err, tt.expected, c)
+		}
+	}
+}
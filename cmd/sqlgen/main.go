@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	_ "github.com/denisenkom/go-mssqldb"
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+
+	q "github.com/core-go/sql"
+	d "github.com/core-go/sql/db"
+	"github.com/core-go/sql/generator"
+	"github.com/core-go/sql/metadata"
+)
+
+func main() {
+	dsn := flag.String("dsn", "", "data source name or SQLite file")
+	driverName := flag.String("driver", "", "database/sql driver name, detected from dsn if empty")
+	tables := flag.String("tables", "", "comma separated table names, all tables if empty")
+	pkg := flag.String("package", "model", "package name of generated files")
+	out := flag.String("out", "", "output directory, one file per table; stdout if empty")
+	filter := flag.Bool("filter", false, "generate Filter types")
+	adapter := flag.Bool("adapter", false, "generate Filter types and adapter.NewSearchAdapter wiring")
+	flag.Parse()
+
+	if len(*dsn) == 0 {
+		fmt.Fprintln(os.Stderr, "sqlgen: -dsn is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(*dsn, *driverName, *tables, *pkg, *out, *filter, *adapter); err != nil {
+		fmt.Fprintln(os.Stderr, "sqlgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(dsn, driverName, tableNames, pkg, out string, filter, adapter bool) error {
+	if len(driverName) == 0 {
+		driverName = d.Detect(dsn)
+	}
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	ctx := context.Background()
+	if err = db.PingContext(ctx); err != nil {
+		return err
+	}
+	driver := q.GetDriver(db)
+	var names []string
+	if len(tableNames) > 0 {
+		for _, name := range strings.Split(tableNames, ",") {
+			if name = strings.TrimSpace(name); len(name) > 0 {
+				names = append(names, name)
+			}
+		}
+	}
+	tables, err := metadata.GetTables(ctx, db, driver, names...)
+	if err != nil {
+		return err
+	}
+	c := generator.Config{Package: pkg, Driver: driver, Filter: filter, Adapter: adapter}
+	if len(out) == 0 {
+		src, err := generator.Generate(c, tables...)
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	if err = os.MkdirAll(out, os.ModePerm); err != nil {
+		return err
+	}
+	for _, table := range tables {
+		src, err := generator.Generate(c, table)
+		if err != nil {
+			return err
+		}
+		file := filepath.Join(out, strings.ToLower(table.Name)+".go")
+		if err = os.WriteFile(file, src, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
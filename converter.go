@@ -0,0 +1,199 @@
+package sql
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"sync"
+)
+
+// Converter defines how the values of a type are written and scanned.
+// Value returns the value to write, which can be a driver.Valuer. Scanner returns the scanner of the pointer to the field.
+type Converter struct {
+	Value   func(v interface{}) interface{}
+	Scanner func(p interface{}) sql.Scanner
+}
+
+// ScannerFunc is a function which implements sql.Scanner.
+type ScannerFunc func(src interface{}) error
+
+func (f ScannerFunc) Scan(src interface{}) error {
+	return f(src)
+}
+
+type converterKey struct {
+	driver string
+	t      reflect.Type
+	tag    string
+}
+
+const arrayConverter = "[]"
+
+var (
+	convertersMu sync.RWMutex
+	converters   = make(map[converterKey]*Converter)
+	// drivers of the converters of each key, which are registered for some drivers
+	dialectConverters = make(map[converterKey]map[string]*Converter)
+)
+
+// RegisterConverter registers the converter of the type, for the drivers, or for all drivers if drivers is empty.
+// The converter of T is also used for the fields of *T: Value receives T and Scanner receives *T.
+func RegisterConverter(t reflect.Type, c Converter, drivers ...string) {
+	registerConverter(converterKey{t: t}, c, drivers)
+}
+
+// RegisterTagConverter registers the converter of the fields with the tag convert:"name".
+func RegisterTagConverter(name string, c Converter, drivers ...string) {
+	registerConverter(converterKey{tag: name}, c, drivers)
+}
+
+// RegisterArrayConverter registers toArray, such as pq.Array, as the converter of the slices, except []byte.
+func RegisterArrayConverter(toArray func(interface{}) interface {
+	driver.Valuer
+	sql.Scanner
+}, drivers ...string) {
+	registerConverter(converterKey{tag: arrayConverter}, NewArrayConverter(toArray), drivers)
+}
+func NewArrayConverter(toArray func(interface{}) interface {
+	driver.Valuer
+	sql.Scanner
+}) Converter {
+	return Converter{
+		Value: func(v interface{}) interface{} {
+			return toArray(v)
+		},
+		Scanner: func(p interface{}) sql.Scanner {
+			return toArray(p)
+		},
+	}
+}
+func registerConverter(key converterKey, c Converter, drivers []string) {
+	convertersMu.Lock()
+	defer convertersMu.Unlock()
+	defer clearFieldScanners()
+	if len(drivers) == 0 {
+		converters[key] = &c
+		return
+	}
+	m, ok := dialectConverters[key]
+	if !ok {
+		m = make(map[string]*Converter)
+		dialectConverters[key] = m
+	}
+	for _, d := range drivers {
+		m[d] = &c
+		key.driver = d
+		converters[key] = &c
+	}
+}
+func getConverter(driver string, key converterKey) *Converter {
+	if len(driver) > 0 {
+		key.driver = driver
+		if c, ok := converters[key]; ok {
+			return c
+		}
+		key.driver = ""
+	} else if c, ok := converters[key]; ok {
+		return c
+	} else if m := dialectConverters[key]; len(m) == 1 {
+		for _, c := range m {
+			return c
+		}
+	}
+	return converters[key]
+}
+
+// FindConverter returns the converter of the field by the tag convert, by the type of the field, or by the array converter if the field is a slice.
+// The converters of the driver have priority over the converters for all drivers.
+// If driver is empty, the converters for all drivers are used, or the converter of a driver if only one driver registers it.
+func FindConverter(driver string, field reflect.StructField) *Converter {
+	convertersMu.RLock()
+	defer convertersMu.RUnlock()
+	if len(converters) == 0 {
+		return nil
+	}
+	if name, ok := field.Tag.Lookup("convert"); ok && len(name) > 0 {
+		return getConverter(driver, converterKey{tag: name})
+	}
+	return findTypeConverter(driver, field.Type)
+}
+func findTypeConverter(driver string, t reflect.Type) *Converter {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if c := getConverter(driver, converterKey{t: t}); c != nil {
+		return c
+	}
+	if t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8 {
+		return getConverter(driver, converterKey{tag: arrayConverter})
+	}
+	return nil
+}
+
+// ConvertArgs converts the args of the registered types, for the raw queries. The nil args and the driver.Valuer args are not changed.
+func ConvertArgs(args []interface{}, options ...string) []interface{} {
+	var driver string
+	if len(options) > 0 {
+		driver = options[0]
+	}
+	var res []interface{}
+	for i, arg := range args {
+		v, ok := convertArg(driver, arg)
+		if !ok {
+			continue
+		}
+		if res == nil {
+			res = make([]interface{}, len(args))
+			copy(res, args)
+		}
+		res[i] = v
+	}
+	if res == nil {
+		return args
+	}
+	return res
+}
+func ConvertArg(arg interface{}, options ...string) interface{} {
+	var driver string
+	if len(options) > 0 {
+		driver = options[0]
+	}
+	v, _ := convertArg(driver, arg)
+	return v
+}
+func convertArg(driver string, arg interface{}) (interface{}, bool) {
+	if isNilValue(arg) || isValuer(arg) {
+		return arg, false
+	}
+	convertersMu.RLock()
+	c := getConverter(driver, converterKey{t: reflect.TypeOf(arg)})
+	convertersMu.RUnlock()
+	if c == nil || c.Value == nil {
+		return arg, false
+	}
+	return c.Value(arg), true
+}
+func isValuer(v interface{}) bool {
+	_, ok := v.(driver.Valuer)
+	return ok
+}
+
+// newConverterScanner returns the scanner of the converter. If the field is a pointer, it is nil for null, and is allocated for the other values.
+func newConverterScanner(c *Converter, field reflect.StructField, x interface{}) sql.Scanner {
+	if field.Type.Kind() != reflect.Ptr {
+		return c.Scanner(x)
+	}
+	p := reflect.ValueOf(x).Elem()
+	return ScannerFunc(func(src interface{}) error {
+		if src == nil {
+			p.Set(reflect.Zero(p.Type()))
+			return nil
+		}
+		v := reflect.New(p.Type().Elem())
+		if err := c.Scanner(v.Interface()).Scan(src); err != nil {
+			return err
+		}
+		p.Set(v)
+		return nil
+	})
+}
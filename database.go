@@ -264,6 +264,33 @@ func InsertBatchWithSchema(ctx context.Context, db *sql.DB, tableName string, mo
 	}
 	return x.RowsAffected()
 }
+
+// InsertBatchIgnore inserts the models and skips the duplicate rows. It returns the number of the inserted rows and the number of the skipped rows.
+func InsertBatchIgnore(ctx context.Context, db *sql.DB, tableName string, models interface{}, options ...*Schema) (int64, int64, error) {
+	return InsertBatchIgnoreWithSchema(ctx, db, tableName, models, nil, nil, options...)
+}
+func InsertBatchIgnoreWithSchema(ctx context.Context, db *sql.DB, tableName string, models interface{}, toArray func(interface{}) interface {
+	driver.Valuer
+	sql.Scanner
+}, buildParam func(i int) string, options ...*Schema) (int64, int64, error) {
+	if buildParam == nil {
+		buildParam = GetBuild(db)
+	}
+	driver := GetDriver(db)
+	query, args, er1 := BuildToInsertIgnoreBatch(tableName, models, driver, toArray, buildParam, options...)
+	if er1 != nil || len(query) == 0 {
+		return 0, 0, er1
+	}
+	x, er2 := db.ExecContext(ctx, query, args...)
+	if er2 != nil {
+		return 0, 0, er2
+	}
+	inserted, er3 := x.RowsAffected()
+	if er3 != nil {
+		return 0, 0, er3
+	}
+	return inserted, int64(reflect.Indirect(reflect.ValueOf(models)).Len()) - inserted, nil
+}
 func UpdateBatch(ctx context.Context, db *sql.DB, tableName string, models interface{}, options ...*Schema) (int64, error) {
 	buildParam := GetBuild(db)
 	driver := GetDriver(db)
@@ -383,6 +410,9 @@ func ExtractBySchema(value interface{}, columns []string, schema map[string]Fiel
 					fieldValue = reflect.Indirect(reflect.ValueOf(fieldValue)).Interface()
 				}
 			}
+			if !isNil {
+				fieldValue, isNil = ToDBValueOrNull(&fdb, fieldValue)
+			}
 			if !fdb.Key {
 				if !isNil {
 					if boolValue, ok := fieldValue.(bool); ok {
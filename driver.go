@@ -20,16 +20,19 @@ func GetDriver(db *sql.DB) string {
 		return DriverNotSupport
 	}
 	driver := reflect.TypeOf(db.Driver()).String()
+	if d, ok := db.Driver().(interface{ Dialect() string }); ok {
+		driver = d.Dialect()
+	}
 	switch driver {
-	case "*pq.Driver":
+	case "*pq.Driver", "postgres":
 		return DriverPostgres
-	case "*godror.drv":
+	case "*godror.drv", "oracle":
 		return DriverOracle
-	case "*mysql.MySQLDriver":
+	case "*mysql.MySQLDriver", "mysql":
 		return DriverMysql
-	case "*mssql.Driver":
+	case "*mssql.Driver", "mssql":
 		return DriverMssql
-	case "*sqlite3.SQLiteDriver":
+	case "*sqlite3.SQLiteDriver", "sqlite3":
 		return DriverSqlite3
 	default:
 		return DriverNotSupport
@@ -61,12 +64,15 @@ func GetBuildByDriver(driver string) func(i int) string {
 }
 func GetBuild(db *sql.DB) func(i int) string {
 	driver := reflect.TypeOf(db.Driver()).String()
+	if d, ok := db.Driver().(interface{ Dialect() string }); ok {
+		driver = d.Dialect()
+	}
 	switch driver {
-	case "*pq.Driver":
+	case "*pq.Driver", "postgres":
 		return BuildDollarParam
-	case "*godror.drv":
+	case "*godror.drv", "oracle":
 		return BuildOracleParam
-	case "*mssql.Driver":
+	case "*mssql.Driver", "mssql":
 		return BuildMsSqlParam
 	default:
 		return BuildParam
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	q "github.com/core-go/sql"
+	"github.com/core-go/sql/export"
 	"github.com/labstack/echo/v4"
 	"net/http"
 	"strconv"
@@ -99,6 +100,10 @@ func (h *Handler) Exec(ctx echo.Context) error {
 		return er0
 	}
 	s.Params = q.ParseDates(s.Params, s.Dates)
+	if er0 = q.BindStatement(q.GetDriver(h.DB), &s); er0 != nil {
+		ctx.String(http.StatusBadRequest, er0.Error())
+		return er0
+	}
 	ps := r.URL.Query()
 	stx := ps.Get("tx")
 	if len(stx) == 0 {
@@ -157,6 +162,10 @@ func (h *Handler) Query(ctx echo.Context) error {
 		return er0
 	}
 	s.Params = q.ParseDates(s.Params, s.Dates)
+	if er0 = q.BindStatement(q.GetDriver(h.DB), &s); er0 != nil {
+		ctx.String(http.StatusBadRequest, er0.Error())
+		return er0
+	}
 	ps := r.URL.Query()
 	stx := ps.Get("tx")
 	if len(stx) == 0 {
@@ -202,6 +211,10 @@ func (h *Handler) QueryOne(ctx echo.Context) error {
 		return er0
 	}
 	s.Params = q.ParseDates(s.Params, s.Dates)
+	if er0 = q.BindStatement(q.GetDriver(h.DB), &s); er0 != nil {
+		ctx.String(http.StatusBadRequest, er0.Error())
+		return er0
+	}
 	ps := r.URL.Query()
 	stx := ps.Get("tx")
 	if len(stx) == 0 {
@@ -256,7 +269,12 @@ func (h *Handler) ExecBatch(ctx echo.Context) error {
 		return er0
 	}
 	l := len(s)
+	driver := q.GetDriver(h.DB)
 	for i := 0; i < l; i++ {
+		if er0 = q.BindStatement(driver, &s[i]); er0 != nil {
+			ctx.String(http.StatusBadRequest, er0.Error())
+			return er0
+		}
 		st := q.Statement{}
 		st.Query = s[i].Query
 		st.Params = q.ParseDates(s[i].Params, s[i].Dates)
@@ -300,6 +318,48 @@ func (h *Handler) ExecBatch(ctx echo.Context) error {
 	return ctx.JSON(http.StatusOK, res)
 }
 
+func (h *Handler) Export(ctx echo.Context) error {
+	r := ctx.Request()
+	s := q.JStatement{}
+	er0 := json.NewDecoder(r.Body).Decode(&s)
+	if er0 != nil {
+		ctx.String(http.StatusBadRequest, er0.Error())
+		return er0
+	}
+	s.Params = q.ParseDates(s.Params, s.Dates)
+	if er0 = q.BindStatement(q.GetDriver(h.DB), &s); er0 != nil {
+		ctx.String(http.StatusBadRequest, er0.Error())
+		return er0
+	}
+	ps := r.URL.Query()
+	format, filename, c := export.ParseConfig(ps)
+	var db q.Executor = h.DB
+	stx := ps.Get("tx")
+	if len(stx) > 0 {
+		tx, er0 := h.Cache.Get(stx)
+		if er0 != nil {
+			ctx.String(http.StatusInternalServerError, er0.Error())
+			return er0
+		}
+		if tx == nil {
+			ctx.String(http.StatusInternalServerError, "cannot get tx from cache. Maybe tx got timeout")
+			return errors.New("cannot get tx from cache. Maybe tx got timeout")
+		}
+		db = tx
+	}
+	rows, er1 := db.QueryContext(r.Context(), s.Query, s.Params...)
+	if er1 != nil {
+		handleError(ctx, 500, er1.Error(), h.Error, er1)
+		return er1
+	}
+	defer rows.Close()
+	_, er2 := export.Respond(r.Context(), ctx.Response(), rows, format, filename, h.Transform, c)
+	if er2 != nil && h.Error != nil {
+		h.Error(r.Context(), er2.Error())
+	}
+	return er2
+}
+
 func handleError(ctx echo.Context, code int, result interface{}, logError func(context.Context, string, ...map[string]interface{}), err error) {
 	if logError != nil {
 		logError(ctx.Request().Context(), err.Error())
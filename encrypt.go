@@ -0,0 +1,124 @@
+package sql
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+const (
+	EncryptRandom        = "true"
+	EncryptDeterministic = "deterministic"
+)
+
+var ErrNoCipher = errors.New("cipher is not set, call SetCipher")
+
+// Cipher encrypts the values of the fields with the tag encrypt. The result is stored as a text.
+// If deterministic is true, the same plaintext must produce the same ciphertext, so the column can be matched by equality.
+type Cipher interface {
+	Encrypt(plaintext []byte, deterministic bool) (string, error)
+	Decrypt(ciphertext string) ([]byte, error)
+}
+
+var (
+	cipherMu      sync.RWMutex
+	defaultCipher Cipher
+)
+
+// SetCipher sets the cipher of the fields with the tag encrypt:"true" or encrypt:"deterministic".
+func SetCipher(c Cipher) {
+	cipherMu.Lock()
+	defaultCipher = c
+	cipherMu.Unlock()
+}
+func GetCipher() Cipher {
+	cipherMu.RLock()
+	defer cipherMu.RUnlock()
+	return defaultCipher
+}
+
+// Encrypted encrypts V when it is written, and decrypts into V when it is scanned. To scan, V must be a pointer.
+// A string or a []byte is encrypted as is, the other types are encrypted as JSON. If Cipher is nil, the cipher of SetCipher is used.
+type Encrypted struct {
+	V             interface{}
+	Deterministic bool
+	Cipher        Cipher
+}
+
+// Encrypt returns the value to write, or to match a column with encrypt:"deterministic" by equality.
+func Encrypt(v interface{}, deterministic bool) Encrypted {
+	return Encrypted{V: v, Deterministic: deterministic}
+}
+
+func (e Encrypted) getCipher() (Cipher, error) {
+	if e.Cipher != nil {
+		return e.Cipher, nil
+	}
+	c := GetCipher()
+	if c == nil {
+		return nil, ErrNoCipher
+	}
+	return c, nil
+}
+func (e Encrypted) Value() (driver.Value, error) {
+	if isNilValue(e.V) {
+		return nil, nil
+	}
+	c, er1 := e.getCipher()
+	if er1 != nil {
+		return nil, er1
+	}
+	var b []byte
+	v := reflect.Indirect(reflect.ValueOf(e.V))
+	if v.Kind() == reflect.String {
+		b = []byte(v.String())
+	} else if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+		b = v.Bytes()
+	} else {
+		var er2 error
+		b, er2 = json.Marshal(v.Interface())
+		if er2 != nil {
+			return nil, er2
+		}
+	}
+	return c.Encrypt(b, e.Deterministic)
+}
+func (e Encrypted) Scan(src interface{}) error {
+	v := reflect.ValueOf(e.V).Elem()
+	var s string
+	switch x := src.(type) {
+	case nil:
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	case []byte:
+		s = string(x)
+	case string:
+		s = x
+	default:
+		return fmt.Errorf("cannot scan %T into an encrypted field", src)
+	}
+	c, er1 := e.getCipher()
+	if er1 != nil {
+		return er1
+	}
+	b, er2 := c.Decrypt(s)
+	if er2 != nil {
+		return er2
+	}
+	if v.Kind() == reflect.Ptr {
+		p := reflect.New(v.Type().Elem())
+		v.Set(p)
+		v = p.Elem()
+	}
+	if v.Kind() == reflect.String {
+		v.SetString(string(b))
+	} else if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+		v.SetBytes(b)
+	} else {
+		return json.Unmarshal(b, v.Addr().Interface())
+	}
+	return nil
+}
@@ -135,12 +135,15 @@ func BinarySearch(id string, a []Entity) (result int, searchCount int) {
 }
 func getBuild(db *sql.DB) func(i int) string {
 	driver := reflect.TypeOf(db.Driver()).String()
+	if d, ok := db.Driver().(interface{ Dialect() string }); ok {
+		driver = d.Dialect()
+	}
 	switch driver {
-	case "*pq.Driver":
+	case "*pq.Driver", "postgres":
 		return buildDollarParam
-	case "*godror.drv":
+	case "*godror.drv", "oracle":
 		return buildOracleParam
-	case "*mssql.Driver":
+	case "*mssql.Driver", "mssql":
 		return buildMsSqlParam
 	default:
 		return buildParam
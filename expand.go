@@ -0,0 +1,247 @@
+package sql
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	inPrefix  = regexp.MustCompile(`(?i)(^|[^a-z0-9_])(not\s+)?in\s*\(\s*$`)
+	inSuffix  = regexp.MustCompile(`^\s*\)`)
+	inOperand = regexp.MustCompile("(?i)([a-z0-9_.$\"`\\[\\]]+)\\s*$")
+)
+
+type queryToken struct {
+	text   string
+	arg    int
+	prefix string
+}
+
+// ExpandIn expands the slice args of "in (?)" into the lists of placeholders, and renumbers the placeholders of the query: ?, $1, :1 or @p1.
+// []byte and driver.Valuer (such as the toArray values) are not expanded. The slices in the other places are not expanded, such as "= any($1)".
+// An empty slice renders "operand in (?)" as the false predicate 1=0, and "operand not in (?)" as 1=1.
+// The operand is a column, a function call such as lower(name), or a tuple such as (a, b). If the operand is not found, the empty slice is not expanded, so the driver returns an error.
+func ExpandIn(query string, args ...interface{}) (string, []interface{}) {
+	sizes := make([]int, len(args))
+	has := false
+	for i, arg := range args {
+		sizes[i] = -1
+		if n, ok := getExpandSize(arg); ok {
+			sizes[i] = n
+			has = true
+		}
+	}
+	if !has {
+		return query, args
+	}
+	tokens := tokenize(query)
+	for i, t := range tokens {
+		if t.arg < 0 || t.arg >= len(args) || sizes[t.arg] < 0 {
+			continue
+		}
+		if !isInList(tokens, i) {
+			sizes[t.arg] = -1
+		} else if sizes[t.arg] == 0 && findOperand(joinTokens(tokens[:i])) < 0 {
+			sizes[t.arg] = -1
+		}
+	}
+	starts := make([]int, len(args))
+	values := make([]interface{}, 0, len(args))
+	for i, arg := range args {
+		starts[i] = len(values) + 1
+		if sizes[i] < 0 {
+			values = append(values, arg)
+			continue
+		}
+		v := reflect.ValueOf(arg)
+		for j := 0; j < sizes[i]; j++ {
+			values = append(values, v.Index(j).Interface())
+		}
+	}
+	var b strings.Builder
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		if t.arg < 0 || t.arg >= len(args) {
+			b.WriteString(t.text)
+			continue
+		}
+		if sizes[t.arg] < 0 {
+			b.WriteString(t.prefix + placeholder(t.prefix, starts[t.arg]))
+			continue
+		}
+		if sizes[t.arg] == 0 {
+			s := b.String()
+			loc := inPrefix.FindStringSubmatchIndex(s)
+			start := findOperand(s)
+			b.Reset()
+			b.WriteString(s[:start])
+			if loc[4] >= 0 {
+				b.WriteString("1=1")
+			} else {
+				b.WriteString("1=0")
+			}
+			next := tokens[i+1].text
+			tokens[i+1].text = next[strings.Index(next, ")")+1:]
+			continue
+		}
+		for j := 0; j < sizes[t.arg]; j++ {
+			if j > 0 {
+				b.WriteString(",")
+			}
+			b.WriteString(t.prefix + placeholder(t.prefix, starts[t.arg]+j))
+		}
+	}
+	return b.String(), values
+}
+
+// findOperand returns the start of the operand of "in (", which is at the end of s, or -1 if it is not found.
+// A function call or a tuple is found by the balanced parentheses. The operand of an expression such as "a + b in (" is not found.
+func findOperand(s string) int {
+	start := findOperandStart(s)
+	if start < 0 {
+		return -1
+	}
+	i := start
+	for i > 0 && isSpace(s[i-1]) {
+		i--
+	}
+	if i > 0 && strings.IndexByte("+-*/%|&^~=<>", s[i-1]) >= 0 {
+		return -1
+	}
+	return start
+}
+func findOperandStart(s string) int {
+	loc := inPrefix.FindStringSubmatchIndex(s)
+	if loc == nil {
+		return -1
+	}
+	i := loc[3]
+	for i > 0 && isSpace(s[i-1]) {
+		i--
+	}
+	if i == 0 {
+		return -1
+	}
+	if s[i-1] != ')' {
+		if operand := inOperand.FindStringIndex(s[:i]); operand != nil {
+			return operand[0]
+		}
+		return -1
+	}
+	depth := 0
+	for i > 0 {
+		i--
+		if s[i] == ')' {
+			depth++
+		} else if s[i] == '(' {
+			depth--
+			if depth == 0 {
+				break
+			}
+		}
+	}
+	if depth != 0 {
+		return -1
+	}
+	if i > 0 && isNamePart(s[i-1]) {
+		if name := inOperand.FindStringIndex(s[:i]); name != nil {
+			return name[0]
+		}
+	}
+	return i
+}
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+func joinTokens(tokens []queryToken) string {
+	var b strings.Builder
+	for _, t := range tokens {
+		b.WriteString(t.text)
+	}
+	return b.String()
+}
+func getExpandSize(arg interface{}) (int, bool) {
+	if arg == nil {
+		return 0, false
+	}
+	if _, ok := arg.(driver.Valuer); ok {
+		return 0, false
+	}
+	if _, ok := arg.([]byte); ok {
+		return 0, false
+	}
+	v := reflect.ValueOf(arg)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return 0, false
+	}
+	return v.Len(), true
+}
+func isInList(tokens []queryToken, i int) bool {
+	if i == 0 || i+1 >= len(tokens) || tokens[i-1].arg >= 0 || tokens[i+1].arg >= 0 {
+		return false
+	}
+	return inPrefix.MatchString(tokens[i-1].text) && inSuffix.MatchString(tokens[i+1].text)
+}
+func placeholder(prefix string, i int) string {
+	if prefix == "?" {
+		return ""
+	}
+	return strconv.Itoa(i)
+}
+
+// tokenize splits the query into the texts and the placeholders. The placeholders in the string literals and in the comments are ignored.
+// The index of ? is its position, the index of $1, :1 or @p1 is its number.
+func tokenize(query string) []queryToken {
+	tokens := make([]queryToken, 0)
+	n := len(query)
+	start := 0
+	position := 0
+	add := func(i int, j int, prefix string, arg int) {
+		tokens = append(tokens, queryToken{text: query[start:i], arg: -1})
+		tokens = append(tokens, queryToken{text: query[i:j], arg: arg, prefix: prefix})
+		start = j
+	}
+	for i := 0; i < n; i++ {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			i = skipQuoted(query, i, c, false) - 1
+		case c == '-' && i+1 < n && query[i+1] == '-':
+			j := strings.IndexByte(query[i:], '\n')
+			if j < 0 {
+				return append(tokens, queryToken{text: query[start:], arg: -1})
+			}
+			i = i + j
+		case c == '/' && i+1 < n && query[i+1] == '*':
+			j := strings.Index(query[i+2:], "*/")
+			if j < 0 {
+				return append(tokens, queryToken{text: query[start:], arg: -1})
+			}
+			i = i + 2 + j + 1
+		case c == '?':
+			add(i, i+1, "?", position)
+			position++
+		case c == '$' || c == ':' || c == '@':
+			prefix := string(c)
+			j := i + 1
+			if c == '@' && j < n && (query[j] == 'p' || query[j] == 'P') {
+				prefix = query[i : j+1]
+				j++
+			}
+			k := j
+			for k < n && query[k] >= '0' && query[k] <= '9' {
+				k++
+			}
+			if k == j || (i > 0 && (isNamePart(query[i-1]) || query[i-1] == c)) {
+				continue
+			}
+			index, _ := strconv.Atoi(query[j:k])
+			add(i, k, prefix, index-1)
+			i = k - 1
+		}
+	}
+	return append(tokens, queryToken{text: query[start:], arg: -1})
+}
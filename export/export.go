@@ -0,0 +1,232 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	q "github.com/core-go/sql"
+)
+
+const (
+	CSV    = "csv"
+	NDJSON = "ndjson"
+)
+
+type Config struct {
+	Header     bool   `yaml:"header" mapstructure:"header" json:"header,omitempty"`
+	Delimiter  rune   `yaml:"delimiter" mapstructure:"delimiter" json:"delimiter,omitempty"`
+	Null       string `yaml:"null" mapstructure:"null" json:"null,omitempty"`
+	TimeFormat string `yaml:"time_format" mapstructure:"time_format" json:"timeFormat,omitempty"`
+	FlushSize  int    `yaml:"flush_size" mapstructure:"flush_size" json:"flushSize,omitempty"`
+}
+
+var DefaultConfig = Config{Header: true, Delimiter: ',', TimeFormat: time.RFC3339, FlushSize: 1000}
+
+type Exporter[F any] struct {
+	DB         *sql.DB
+	BuildQuery func(F) (string, []interface{})
+	Transform  func(s string) string
+	Config     Config
+}
+
+// NewExporter creates the exporter of the queries built from the filter. The columns are named by the json tags of T.
+func NewExporter[T any, F any](db *sql.DB, buildQuery func(F) (string, []interface{}), options ...Config) *Exporter[F] {
+	var t T
+	c := DefaultConfig
+	if len(options) > 0 {
+		c = options[0]
+	}
+	return &Exporter[F]{DB: db, BuildQuery: buildQuery, Transform: UseJson(reflect.TypeOf(t)), Config: c}
+}
+func (e *Exporter[F]) Export(ctx context.Context, w io.Writer, format string, filter F) (int64, error) {
+	query, args := e.BuildQuery(filter)
+	if format == NDJSON {
+		return WriteNDJSON(ctx, e.DB, w, e.Transform, e.Config, query, args...)
+	}
+	return WriteCSV(ctx, e.DB, w, e.Transform, e.Config, query, args...)
+}
+
+// UseJson returns the transform function, which maps the columns of the model to the json names.
+func UseJson(modelType reflect.Type) func(s string) string {
+	if modelType.Kind() == reflect.Ptr {
+		modelType = modelType.Elem()
+	}
+	names := make(map[string]string)
+	if modelType.Kind() == reflect.Struct {
+		for _, f := range q.CreateSchema(modelType).Columns {
+			names[strings.ToLower(f.Column)] = f.JSON
+		}
+	}
+	return func(s string) string {
+		if name, ok := names[strings.ToLower(s)]; ok {
+			return name
+		}
+		return s
+	}
+}
+
+func WriteCSV(ctx context.Context, db q.Executor, w io.Writer, transform func(s string) string, c Config, query string, args ...interface{}) (int64, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	return WriteCSVRows(ctx, rows, w, transform, c)
+}
+func WriteCSVRows(ctx context.Context, rows *sql.Rows, w io.Writer, transform func(s string) string, c Config) (int64, error) {
+	cols, er2 := rows.Columns()
+	if er2 != nil {
+		return 0, er2
+	}
+	writer := csv.NewWriter(w)
+	if c.Delimiter != 0 {
+		writer.Comma = c.Delimiter
+	}
+	if c.Header {
+		if err := writer.Write(names(cols, transform)); err != nil {
+			return 0, err
+		}
+	}
+	values, pointers := makePointers(len(cols))
+	record := make([]string, len(cols))
+	var count int64
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return count, err
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return count, err
+		}
+		for i, v := range values {
+			record[i] = format(v, c)
+		}
+		if err := writer.Write(record); err != nil {
+			return count, err
+		}
+		count++
+		if c.FlushSize > 0 && count%int64(c.FlushSize) == 0 {
+			writer.Flush()
+			if err := writer.Error(); err != nil {
+				return count, err
+			}
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return count, err
+	}
+	return count, rows.Err()
+}
+
+// WriteNDJSON writes one JSON object per line, the keys are in the order of the columns. The times are formatted by TimeFormat, as WriteCSV.
+func WriteNDJSON(ctx context.Context, db q.Executor, w io.Writer, transform func(s string) string, c Config, query string, args ...interface{}) (int64, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	return WriteNDJSONRows(ctx, rows, w, transform, c)
+}
+func WriteNDJSONRows(ctx context.Context, rows *sql.Rows, w io.Writer, transform func(s string) string, c Config) (int64, error) {
+	cols, er2 := rows.Columns()
+	if er2 != nil {
+		return 0, er2
+	}
+	keys := make([][]byte, len(cols))
+	for i, name := range names(cols, transform) {
+		keys[i], _ = json.Marshal(name)
+	}
+	values, pointers := makePointers(len(cols))
+	var buf bytes.Buffer
+	var count int64
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return count, err
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return count, err
+		}
+		buf.Reset()
+		buf.WriteByte('{')
+		for i, v := range values {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.Write(keys[i])
+			buf.WriteByte(':')
+			switch x := v.(type) {
+			case []byte:
+				v = string(x)
+			case time.Time:
+				v = formatTime(x, c)
+			}
+			b, err := json.Marshal(v)
+			if err != nil {
+				return count, err
+			}
+			buf.Write(b)
+		}
+		buf.WriteString("}\n")
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, rows.Err()
+}
+
+func names(cols []string, transform func(s string) string) []string {
+	if transform == nil {
+		return cols
+	}
+	names := make([]string, len(cols))
+	for i, col := range cols {
+		names[i] = transform(col)
+	}
+	return names
+}
+func makePointers(n int) ([]interface{}, []interface{}) {
+	values := make([]interface{}, n)
+	pointers := make([]interface{}, n)
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	return values, pointers
+}
+func format(v interface{}, c Config) string {
+	switch x := v.(type) {
+	case nil:
+		return c.Null
+	case []byte:
+		return string(x)
+	case string:
+		return x
+	case time.Time:
+		return formatTime(x, c)
+	case bool:
+		return strconv.FormatBool(x)
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	default:
+		return fmt.Sprint(x)
+	}
+}
+
+// formatTime formats the time by TimeFormat of the config, or by RFC3339, for both CSV and NDJSON.
+func formatTime(t time.Time, c Config) string {
+	if len(c.TimeFormat) > 0 {
+		return t.Format(c.TimeFormat)
+	}
+	return t.Format(time.RFC3339)
+}
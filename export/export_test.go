@@ -0,0 +1,41 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/core-go/sql/mock"
+)
+
+func TestWriteCSVAndNDJSONFormatTimes(t *testing.T) {
+	at := time.Date(2024, 3, 1, 10, 30, 0, 0, time.UTC)
+	c := Config{Header: true, Delimiter: ';', Null: "NULL", TimeFormat: "2006-01-02"}
+	db, rec := mock.New("postgres")
+	rec.ExpectRegexp("^select").WillReturnRows([]string{"id", "name", "createdat"},
+		[]interface{}{int64(1), "a;b", at}, []interface{}{int64(2), nil, at}).Times(2)
+	transform := func(s string) string {
+		return strings.ToUpper(s)
+	}
+
+	var csv bytes.Buffer
+	n, err := WriteCSV(context.Background(), db, &csv, transform, c, "select id, name, createdat from users")
+	if err != nil || n != 2 {
+		t.Fatalf("expected 2 rows, got %d %v", n, err)
+	}
+	if expected := "ID;NAME;CREATEDAT\n1;\"a;b\";2024-03-01\n2;NULL;2024-03-01\n"; csv.String() != expected {
+		t.Errorf("expected %q, got %q", expected, csv.String())
+	}
+
+	var ndjson bytes.Buffer
+	n, err = WriteNDJSON(context.Background(), db, &ndjson, transform, c, "select id, name, createdat from users")
+	if err != nil || n != 2 {
+		t.Fatalf("expected 2 rows, got %d %v", n, err)
+	}
+	expected := "{\"ID\":1,\"NAME\":\"a;b\",\"CREATEDAT\":\"2024-03-01\"}\n{\"ID\":2,\"NAME\":null,\"CREATEDAT\":\"2024-03-01\"}\n"
+	if ndjson.String() != expected {
+		t.Errorf("expected %q, got %q", expected, ndjson.String())
+	}
+}
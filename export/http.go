@@ -0,0 +1,100 @@
+package export
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+
+	q "github.com/core-go/sql"
+)
+
+type flushWriter struct {
+	writer  io.Writer
+	flusher http.Flusher
+}
+
+func (w *flushWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	if err == nil {
+		w.flusher.Flush()
+	}
+	return n, err
+}
+
+// Download streams the result of the query as an attachment. If the query fails, nothing is written and the error is returned.
+func Download(ctx context.Context, w http.ResponseWriter, db q.Executor, format string, filename string, transform func(s string) string, c Config, query string, args ...interface{}) (int64, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	return Respond(ctx, w, rows, format, filename, transform, c)
+}
+
+// Respond writes the rows to the response. Once the rows are being written, the errors cannot change the status code, so they are only returned.
+func Respond(ctx context.Context, w http.ResponseWriter, rows *sql.Rows, format string, filename string, transform func(s string) string, c Config) (int64, error) {
+	contentType := "text/csv; charset=utf-8"
+	if format == NDJSON {
+		contentType = "application/x-ndjson"
+	}
+	w.Header().Set("Content-Type", contentType)
+	if len(filename) > 0 {
+		// the filename is quoted or encoded, and is empty if it has the control characters
+		if disposition := mime.FormatMediaType("attachment", map[string]string{"filename": filename}); len(disposition) > 0 {
+			w.Header().Set("Content-Disposition", disposition)
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+	var out io.Writer = w
+	if flusher, ok := w.(http.Flusher); ok {
+		out = &flushWriter{writer: w, flusher: flusher}
+	}
+	buf := bufio.NewWriterSize(out, 32*1024)
+	var count int64
+	var err error
+	if format == NDJSON {
+		count, err = WriteNDJSONRows(ctx, rows, buf, transform, c)
+	} else {
+		count, err = WriteCSVRows(ctx, rows, buf, transform, c)
+	}
+	if er2 := buf.Flush(); err == nil {
+		err = er2
+	}
+	return count, err
+}
+
+func (e *Exporter[F]) Download(ctx context.Context, w http.ResponseWriter, format string, filename string, filter F) (int64, error) {
+	query, args := e.BuildQuery(filter)
+	return Download(ctx, w, e.DB, format, filename, e.Transform, e.Config, query, args...)
+}
+
+// ParseConfig reads the format, filename, header, delimiter and null from the query string.
+func ParseConfig(ps url.Values) (string, string, Config) {
+	c := DefaultConfig
+	format := ps.Get("format")
+	if format != NDJSON {
+		format = CSV
+	}
+	filename := ps.Get("filename")
+	if len(filename) == 0 {
+		filename = "export." + format
+	}
+	if ps.Get("header") == "false" {
+		c.Header = false
+	}
+	if delimiter := ps.Get("delimiter"); len(delimiter) > 0 {
+		if delimiter == "tab" {
+			c.Delimiter = '\t'
+		} else {
+			c.Delimiter = []rune(delimiter)[0]
+		}
+	}
+	if null, ok := ps["null"]; ok && len(null) > 0 {
+		c.Null = null[0]
+	}
+	return format, filename, c
+}
@@ -0,0 +1,65 @@
+package feed
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	q "github.com/core-go/sql"
+)
+
+// CheckpointStore loads and saves the position of each feed. Load returns nil if the feed has no position yet.
+type CheckpointStore interface {
+	Load(ctx context.Context, name string) ([]byte, error)
+	Save(ctx context.Context, name string, position []byte) error
+}
+
+// SqlCheckpointStore keeps the positions in a table, such as:
+// create table checkpoints (name varchar(100) primary key, position varchar(1000), updatedat timestamp)
+type SqlCheckpointStore struct {
+	db         *sql.DB
+	tableName  string
+	name       string
+	position   string
+	updatedAt  string
+	BuildParam func(i int) string
+}
+
+// NewSqlCheckpointStore creates a SqlCheckpointStore. options are the columns of name, position and updatedAt, "name", "position" and "updatedat" by default.
+func NewSqlCheckpointStore(db *sql.DB, tableName string, options ...string) *SqlCheckpointStore {
+	columns := []string{"name", "position", "updatedat"}
+	for i := 0; i < len(options) && i < len(columns); i++ {
+		if len(options[i]) > 0 {
+			columns[i] = options[i]
+		}
+	}
+	return &SqlCheckpointStore{db: db, tableName: tableName, name: columns[0], position: columns[1], updatedAt: columns[2], BuildParam: q.GetBuild(db)}
+}
+
+func (s *SqlCheckpointStore) Load(ctx context.Context, name string) ([]byte, error) {
+	query := fmt.Sprintf("select %s from %s where %s = %s", s.position, s.tableName, s.name, s.BuildParam(1))
+	var position sql.NullString
+	err := s.db.QueryRowContext(ctx, query, name).Scan(&position)
+	if err == sql.ErrNoRows || (err == nil && !position.Valid) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []byte(position.String), nil
+}
+func (s *SqlCheckpointStore) Save(ctx context.Context, name string, position []byte) error {
+	now := time.Now()
+	query := fmt.Sprintf("update %s set %s = %s, %s = %s where %s = %s", s.tableName, s.position, s.BuildParam(1), s.updatedAt, s.BuildParam(2), s.name, s.BuildParam(3))
+	res, err := s.db.ExecContext(ctx, query, string(position), now, name)
+	if err != nil {
+		return err
+	}
+	if n, er1 := res.RowsAffected(); er1 == nil && n > 0 {
+		return nil
+	}
+	query = fmt.Sprintf("insert into %s (%s, %s, %s) values (%s, %s, %s)", s.tableName, s.name, s.position, s.updatedAt, s.BuildParam(1), s.BuildParam(2), s.BuildParam(3))
+	_, err = s.db.ExecContext(ctx, query, name, string(position), now)
+	return err
+}
@@ -0,0 +1,223 @@
+package feed
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	q "github.com/core-go/sql"
+)
+
+// Feed polls the rows changed since the checkpoint, by the updatedAt or version column, without CDC.
+// The rows are read by the keyset (cursor, primary key), so the rows with the same cursor value are not skipped, then they are passed to Handle in batches.
+// The checkpoint is saved after Handle succeeds, so the delivery is at least once.
+// The rows which are committed with a cursor value less than the checkpoint are missed, so set Delay for the updatedAt column to wait for the long transactions.
+type Feed[T any] struct {
+	db          *sql.DB
+	tableName   string
+	fields      string
+	Name        string
+	columns     []string
+	indexes     []int
+	fieldsIndex map[string]int
+	Store       CheckpointStore
+	Handle      func(ctx context.Context, models []T) error
+	BatchSize   int
+	Interval    time.Duration
+	// Delay excludes the rows whose updatedAt is later than now - Delay. It is used only if the cursor is time.Time or *time.Time
+	Delay      time.Duration
+	Driver     string
+	BuildParam func(i int) string
+	position   []interface{}
+	loaded     bool
+}
+
+// NewFeed creates a Feed of the table, which is named name in the checkpoint store. cursorField is the name of the updatedAt or version field.
+// options[0] is the checkpoint store, which is a SqlCheckpointStore of the table "checkpoints" by default.
+func NewFeed[T any](db *sql.DB, tableName string, name string, cursorField string, handle func(context.Context, []T) error, batchSize int, interval time.Duration, options ...CheckpointStore) (*Feed[T], error) {
+	var t T
+	modelType := reflect.TypeOf(t)
+	if modelType == nil || modelType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("T must be a struct")
+	}
+	index := q.FindFieldIndex(modelType, cursorField)
+	if index < 0 {
+		return nil, fmt.Errorf("cannot find the field '%s'", cursorField)
+	}
+	_, column, exist := q.GetFieldByIndex(modelType, index)
+	if !exist {
+		column = strings.ToLower(cursorField)
+	}
+	driver := q.GetDriver(db)
+	schema := q.CreateSchema(modelType, driver)
+	if len(schema.Keys) == 0 {
+		return nil, fmt.Errorf("require primary key for table '%s'", tableName)
+	}
+	columns := []string{column}
+	indexes := []int{index}
+	for _, k := range schema.Keys {
+		columns = append(columns, k.Column)
+		indexes = append(indexes, k.Index)
+	}
+	fieldsIndex, err := q.GetColumnIndexes(modelType)
+	if err != nil {
+		return nil, err
+	}
+	var store CheckpointStore
+	if len(options) > 0 && options[0] != nil {
+		store = options[0]
+	} else {
+		store = NewSqlCheckpointStore(db, "checkpoints")
+	}
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	return &Feed[T]{db: db, tableName: tableName, fields: q.BuildFieldsBySchema(schema), Name: name, columns: columns, indexes: indexes, fieldsIndex: fieldsIndex, Store: store, Handle: handle, BatchSize: batchSize, Interval: interval, Driver: driver, BuildParam: q.GetBuild(db)}, nil
+}
+
+// Run polls until ctx is done. It waits for Interval when there is no more change, or when Poll fails.
+func (f *Feed[T]) Run(ctx context.Context, options ...func(error)) error {
+	var onError func(error)
+	if len(options) > 0 {
+		onError = options[0]
+	}
+	for {
+		n, err := f.Poll(ctx)
+		if err != nil && onError != nil && ctx.Err() == nil {
+			onError(err)
+		}
+		if err != nil || n < f.BatchSize {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(f.Interval):
+			}
+		} else if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+// Poll reads a batch of the changed rows, passes them to Handle, then saves the checkpoint. It returns the number of the rows.
+func (f *Feed[T]) Poll(ctx context.Context) (int, error) {
+	if !f.loaded {
+		if err := f.load(ctx); err != nil {
+			return 0, err
+		}
+	}
+	query, args := f.buildQuery()
+	var models []T
+	if err := q.Query(ctx, f.db, f.fieldsIndex, &models, query, args...); err != nil {
+		return 0, err
+	}
+	if len(models) == 0 {
+		return 0, nil
+	}
+	if err := f.Handle(ctx, models); err != nil {
+		return 0, err
+	}
+	v := reflect.ValueOf(models[len(models)-1])
+	position := make([]interface{}, len(f.indexes))
+	for i, index := range f.indexes {
+		position[i] = v.Field(index).Interface()
+	}
+	data, err := json.Marshal(position)
+	if err != nil {
+		return 0, err
+	}
+	if err = f.Store.Save(ctx, f.Name, data); err != nil {
+		return 0, err
+	}
+	f.position = position
+	return len(models), nil
+}
+
+// Reset sets the position of the feed, to read the rows again, or to skip them. The position is the cursor value then the primary key values. It is not saved until the next batch.
+func (f *Feed[T]) Reset(position ...interface{}) {
+	if len(position) == 0 {
+		f.position = nil
+	} else {
+		f.position = position
+	}
+	f.loaded = true
+}
+
+// load gets the position from the store, and converts each value to the type of its field.
+func (f *Feed[T]) load(ctx context.Context) error {
+	data, err := f.Store.Load(ctx, f.Name)
+	if err != nil {
+		return err
+	}
+	f.loaded = true
+	if len(data) == 0 {
+		f.position = nil
+		return nil
+	}
+	var raws []json.RawMessage
+	if err = json.Unmarshal(data, &raws); err != nil {
+		return err
+	}
+	if len(raws) != len(f.indexes) {
+		return fmt.Errorf("invalid checkpoint of feed '%s'", f.Name)
+	}
+	var t T
+	modelType := reflect.TypeOf(t)
+	position := make([]interface{}, len(raws))
+	for i, raw := range raws {
+		p := reflect.New(modelType.Field(f.indexes[i]).Type)
+		if err = json.Unmarshal(raw, p.Interface()); err != nil {
+			return err
+		}
+		position[i] = p.Elem().Interface()
+	}
+	f.position = position
+	return nil
+}
+
+// buildQuery builds the keyset query: (cursor, key1, key2) > (v, k1, k2) is expanded to be supported by all databases.
+func (f *Feed[T]) buildQuery() (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	i := 1
+	if f.Delay > 0 {
+		var t T
+		ft := reflect.TypeOf(t).Field(f.indexes[0]).Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft == reflect.TypeOf(time.Time{}) {
+			conditions = append(conditions, fmt.Sprintf("%s < %s", f.columns[0], f.BuildParam(i)))
+			args = append(args, time.Now().Add(-f.Delay))
+			i++
+		}
+	}
+	if f.position != nil {
+		var ors []string
+		for j := range f.columns {
+			ands := make([]string, 0, j+1)
+			for k := 0; k < j; k++ {
+				ands = append(ands, fmt.Sprintf("%s = %s", f.columns[k], f.BuildParam(i)))
+				args = append(args, f.position[k])
+				i++
+			}
+			ands = append(ands, fmt.Sprintf("%s > %s", f.columns[j], f.BuildParam(i)))
+			args = append(args, f.position[j])
+			i++
+			ors = append(ors, "("+strings.Join(ands, " and ")+")")
+		}
+		conditions = append(conditions, "("+strings.Join(ors, " or ")+")")
+	} else {
+		conditions = append(conditions, fmt.Sprintf("%s is not null", f.columns[0]))
+	}
+	query := fmt.Sprintf("select %s from %s where %s order by %s", f.fields, f.tableName, strings.Join(conditions, " and "), strings.Join(f.columns, ", "))
+	if f.Driver == q.DriverOracle || f.Driver == q.DriverMssql {
+		query = query + fmt.Sprintf(" offset 0 rows fetch next %d rows only", f.BatchSize)
+	} else {
+		query = query + fmt.Sprintf(" limit %d", f.BatchSize)
+	}
+	return query, args
+}
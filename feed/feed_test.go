@@ -0,0 +1,133 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/core-go/sql/mock"
+)
+
+type testEvent struct {
+	Id        string     `gorm:"column:id;primary_key"`
+	Name      string     `gorm:"column:name"`
+	UpdatedAt *time.Time `gorm:"column:updatedat"`
+}
+
+var (
+	eventColumns = []string{"id", "name", "updatedat"}
+	updatedAt    = time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+)
+
+func newFeed(t *testing.T, dialect string, handle func(context.Context, []testEvent) error) (*Feed[testEvent], *mock.Recorder) {
+	db, rec := mock.New(dialect)
+	f, err := NewFeed[testEvent](db, "events", "events", "UpdatedAt", handle, 2, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f, rec
+}
+func handleNothing(context.Context, []testEvent) error {
+	return nil
+}
+
+func TestPollByDialect(t *testing.T) {
+	tests := []struct {
+		dialect string
+		query   string
+	}{
+		{"postgres", "select id,name,updatedat from events where updatedat is not null order by updatedat, id limit 2"},
+		{"mysql", "select id,name,updatedat from events where updatedat is not null order by updatedat, id limit 2"},
+		{"oracle", "select id,name,updatedat from events where updatedat is not null order by updatedat, id offset 0 rows fetch next 2 rows only"},
+		{"mssql", "select id,name,updatedat from events where updatedat is not null order by updatedat, id offset 0 rows fetch next 2 rows only"},
+	}
+	for _, tt := range tests {
+		f, rec := newFeed(t, tt.dialect, handleNothing)
+		if n, err := f.Poll(context.Background()); n != 0 || err != nil {
+			t.Fatalf("%s: expected no row, got %d %v", tt.dialect, n, err)
+		}
+		rec.AssertStatement(t, tt.query)
+	}
+}
+
+func TestPollSavesCheckpoint(t *testing.T) {
+	var handled []testEvent
+	f, rec := newFeed(t, "postgres", func(ctx context.Context, events []testEvent) error {
+		handled = append(handled, events...)
+		return nil
+	})
+	rec.ExpectRegexp("^select id,name,updatedat").WillReturnRows(eventColumns,
+		[]interface{}{"1", "a", updatedAt}, []interface{}{"2", "b", updatedAt})
+	n, err := f.Poll(context.Background())
+	if err != nil || n != 2 || len(handled) != 2 {
+		t.Fatalf("expected 2 rows, got %d %v", n, err)
+	}
+	rec.AssertStatement(t, "select position from checkpoints where name = $1", "events")
+	rec.AssertStatement(t, "update checkpoints set position = $1, updatedat = $2 where name = $3", `["2024-03-01T10:00:00Z","2"]`, mock.AnyArg, "events")
+	f.Poll(context.Background())
+	rec.AssertStatement(t, "select id,name,updatedat from events where ((updatedat > $1) or (updatedat = $2 and id > $3)) order by updatedat, id limit 2", updatedAt, updatedAt, "2")
+}
+
+func TestPollLoadsCheckpoint(t *testing.T) {
+	f, rec := newFeed(t, "mysql", handleNothing)
+	rec.ExpectRegexp("^select position").WillReturnRows([]string{"position"}, []interface{}{`["2024-03-01T10:00:00Z","7"]`})
+	if _, err := f.Poll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	rec.AssertStatement(t, "select id,name,updatedat from events where ((updatedat > ?) or (updatedat = ? and id > ?)) order by updatedat, id limit 2", updatedAt, updatedAt, "7")
+
+	f, rec = newFeed(t, "mysql", handleNothing)
+	rec.ExpectRegexp("^select position").WillReturnRows([]string{"position"}, []interface{}{`["2024-03-01T10:00:00Z"]`})
+	if _, err := f.Poll(context.Background()); err == nil {
+		t.Fatal("expected the error of the invalid checkpoint")
+	}
+}
+
+func TestPollDelayOfTimePointer(t *testing.T) {
+	f, rec := newFeed(t, "postgres", handleNothing)
+	f.Delay = time.Minute
+	f.Reset()
+	start := time.Now()
+	f.Poll(context.Background())
+	statements := rec.Statements()
+	if len(statements) != 1 || statements[0].Query != "select id,name,updatedat from events where updatedat < $1 and updatedat is not null order by updatedat, id limit 2" {
+		t.Fatalf("expected the condition of Delay, got %v", statements)
+	}
+	if before := statements[0].Args[0].(time.Time); before.Before(start.Add(-time.Minute)) || before.After(time.Now().Add(-time.Minute)) {
+		t.Errorf("expected the rows of 1 minute ago, got %v", start.Sub(before))
+	}
+}
+
+func TestPollDoesNotSaveIfHandleFails(t *testing.T) {
+	failed := errors.New("handle failed")
+	f, rec := newFeed(t, "postgres", func(context.Context, []testEvent) error {
+		return failed
+	})
+	rec.ExpectRegexp("^select id,name,updatedat").WillReturnRows(eventColumns, []interface{}{"1", "a", updatedAt})
+	if _, err := f.Poll(context.Background()); err != failed {
+		t.Fatalf("expected the error of Handle, got %v", err)
+	}
+	for _, s := range rec.Statements() {
+		if !strings.HasPrefix(s.Query, "select") {
+			t.Fatalf("expected the checkpoint not to be saved, got %v", s)
+		}
+	}
+	if f.position != nil {
+		t.Errorf("expected the position not to move, got %v", f.position)
+	}
+}
+
+func TestSqlCheckpointStoreInsertsNewFeed(t *testing.T) {
+	db, rec := mock.New("postgres")
+	rec.ExpectRegexp("^update").WillReturnResult(0, 0)
+	s := NewSqlCheckpointStore(db, "checkpoints")
+	if err := s.Save(context.Background(), "events", []byte(`[1]`)); err != nil {
+		t.Fatal(err)
+	}
+	rec.AssertStatement(t, "insert into checkpoints (name, position, updatedat) values ($1, $2, $3)", "events", "[1]", mock.AnyArg)
+	if position, err := s.Load(context.Background(), "other"); position != nil || err != nil {
+		t.Fatalf("expected no position, got %s %v", position, err)
+	}
+}
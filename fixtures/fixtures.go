@@ -0,0 +1,330 @@
+package fixtures
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	q "github.com/core-go/sql"
+	"github.com/core-go/sql/metadata"
+)
+
+const maxParams = 900
+
+var template = regexp.MustCompile(`^\{\{\s*(now|today)\s*(?:([+-])\s*(\d+)\s*([smhdwMy]))?\s*\}\}$`)
+
+type Fixtures struct {
+	DB     *sql.DB
+	Driver string
+	// Tables is the insert order. If it is empty, the order is resolved from the foreign keys.
+	Tables    []string
+	Data      map[string][]map[string]interface{}
+	Unmarshal map[string]func([]byte, interface{}) error
+	Now       func() time.Time
+	TxKey     string
+	sorted    []string
+}
+
+// NewFixtures creates the fixtures loader. JSON files are supported by default, YAML files need yaml.Unmarshal.
+func NewFixtures(db *sql.DB, yamlUnmarshal ...func([]byte, interface{}) error) *Fixtures {
+	unmarshal := map[string]func([]byte, interface{}) error{"json": unmarshalJSON}
+	if len(yamlUnmarshal) > 0 && yamlUnmarshal[0] != nil {
+		unmarshal["yaml"] = yamlUnmarshal[0]
+		unmarshal["yml"] = yamlUnmarshal[0]
+	}
+	return &Fixtures{DB: db, Driver: q.GetDriver(db), Data: make(map[string][]map[string]interface{}), Unmarshal: unmarshal, Now: time.Now}
+}
+
+func (f *Fixtures) ReadFile(paths ...string) error {
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		format := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+		if err = f.Read(format, data); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return nil
+}
+func (f *Fixtures) Read(format string, data []byte) error {
+	unmarshal, ok := f.Unmarshal[format]
+	if !ok {
+		return fmt.Errorf("unmarshal function of %s is required", format)
+	}
+	tables := make(map[string][]map[string]interface{})
+	if err := unmarshal(data, &tables); err != nil {
+		return err
+	}
+	for table, rows := range tables {
+		f.Data[table] = append(f.Data[table], rows...)
+	}
+	f.sorted = nil
+	return nil
+}
+
+// Load inserts the rows, inside the transaction of the context if any.
+func (f *Fixtures) Load(ctx context.Context) error {
+	tables, err := f.resolveTables(ctx)
+	if err != nil {
+		return err
+	}
+	exec := q.GetExec(ctx, f.DB, f.TxKey)
+	buildParam := q.GetBuildByDriver(f.Driver)
+	for _, table := range tables {
+		rows := f.Data[table]
+		if len(rows) == 0 {
+			continue
+		}
+		models, err := f.toModels(rows)
+		if err != nil {
+			return fmt.Errorf("%s: %w", table, err)
+		}
+		size := models.Len()
+		n := q.Max(1, maxParams/models.Type().Elem().NumField())
+		for i := 0; i < size; i = i + n {
+			chunk := models.Slice(i, q.Min(i+n, size)).Interface()
+			query, args, er1 := q.BuildToInsertBatchWithSchema(table, chunk, f.Driver, nil, buildParam)
+			if er1 != nil {
+				return er1
+			}
+			if _, er2 := exec.ExecContext(ctx, query, args...); er2 != nil {
+				return fmt.Errorf("%s: %w", table, er2)
+			}
+		}
+	}
+	return nil
+}
+
+// Clear deletes all rows of the fixture tables, in the reverse order of Load.
+func (f *Fixtures) Clear(ctx context.Context) error {
+	tables, err := f.resolveTables(ctx)
+	if err != nil {
+		return err
+	}
+	exec := q.GetExec(ctx, f.DB, f.TxKey)
+	for i := len(tables) - 1; i >= 0; i-- {
+		if _, err := exec.ExecContext(ctx, "delete from "+tables[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+func (f *Fixtures) Reset(ctx context.Context) error {
+	if err := f.Clear(ctx); err != nil {
+		return err
+	}
+	return f.Load(ctx)
+}
+
+// Begin resets the tables inside a new transaction, and returns the context of this transaction and the function to roll it back.
+func (f *Fixtures) Begin(ctx context.Context) (context.Context, func() error, error) {
+	tx, err := f.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return ctx, nil, err
+	}
+	key := f.TxKey
+	if len(key) == 0 {
+		key = "tx"
+	}
+	c := context.WithValue(ctx, key, tx)
+	if err = f.Reset(c); err != nil {
+		tx.Rollback()
+		return ctx, nil, err
+	}
+	return c, tx.Rollback, nil
+}
+
+func (f *Fixtures) resolveTables(ctx context.Context) ([]string, error) {
+	if len(f.Tables) > 0 {
+		return f.Tables, nil
+	}
+	if f.sorted != nil {
+		return f.sorted, nil
+	}
+	tables := make([]string, 0, len(f.Data))
+	for table := range f.Data {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	dependencies := make(map[string][]string)
+	for _, table := range tables {
+		keys, err := metadata.GetForeignKeys(ctx, f.DB, f.Driver, table)
+		if err != nil {
+			return nil, err
+		}
+		for _, k := range keys {
+			if !strings.EqualFold(k.ReferencedTable, table) {
+				dependencies[table] = append(dependencies[table], k.ReferencedTable)
+			}
+		}
+	}
+	f.sorted = SortTables(tables, dependencies)
+	return f.sorted, nil
+}
+
+// SortTables orders the tables so that the referenced tables come first. The tables of a cycle keep their order.
+func SortTables(tables []string, dependencies map[string][]string) []string {
+	sorted := make([]string, 0, len(tables))
+	state := make(map[string]int)
+	exist := make(map[string]string)
+	for _, table := range tables {
+		exist[strings.ToLower(table)] = table
+	}
+	var visit func(table string)
+	visit = func(table string) {
+		if state[table] != 0 {
+			return
+		}
+		state[table] = 1
+		for _, ref := range dependencies[table] {
+			if t, ok := exist[strings.ToLower(ref)]; ok {
+				visit(t)
+			}
+		}
+		state[table] = 2
+		sorted = append(sorted, table)
+	}
+	for _, table := range tables {
+		visit(table)
+	}
+	return sorted
+}
+
+func (f *Fixtures) toModels(rows []map[string]interface{}) (reflect.Value, error) {
+	columns := make([]string, 0)
+	exist := make(map[string]bool)
+	for _, row := range rows {
+		for column := range row {
+			if !exist[column] {
+				exist[column] = true
+				columns = append(columns, column)
+			}
+		}
+	}
+	sort.Strings(columns)
+	valueType := reflect.TypeOf((*interface{})(nil))
+	fields := make([]reflect.StructField, len(columns))
+	for i, column := range columns {
+		fields[i] = reflect.StructField{Name: "F" + strconv.Itoa(i), Type: valueType, Tag: reflect.StructTag(`gorm:"column:` + column + `"`)}
+	}
+	modelType := reflect.StructOf(fields)
+	models := reflect.MakeSlice(reflect.SliceOf(modelType), len(rows), len(rows))
+	for i, row := range rows {
+		model := models.Index(i)
+		for j, column := range columns {
+			v, ok := row[column]
+			if !ok || v == nil {
+				continue
+			}
+			value, err := f.toValue(v)
+			if err != nil {
+				return models, fmt.Errorf("%s: %w", column, err)
+			}
+			model.Field(j).Set(reflect.ValueOf(&value))
+		}
+	}
+	return models, nil
+}
+func (f *Fixtures) toValue(v interface{}) (interface{}, error) {
+	switch x := v.(type) {
+	case string:
+		return f.Parse(x)
+	case json.Number:
+		if i, err := x.Int64(); err == nil {
+			return i, nil
+		}
+		return x.Float64()
+	case map[string]interface{}, map[interface{}]interface{}, []interface{}:
+		b, err := json.Marshal(normalize(x))
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	default:
+		return v, nil
+	}
+}
+
+// Parse returns the time of {{now}}, {{today}}, {{now-2h}} or {{today+7d}}, otherwise returns the string.
+func (f *Fixtures) Parse(s string) (interface{}, error) {
+	m := template.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return s, nil
+	}
+	now := time.Now
+	if f.Now != nil {
+		now = f.Now
+	}
+	t := now()
+	if m[1] == "today" {
+		t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	}
+	if len(m[2]) == 0 {
+		return t, nil
+	}
+	n, err := strconv.Atoi(m[3])
+	if err != nil {
+		return nil, err
+	}
+	if m[2] == "-" {
+		n = -n
+	}
+	switch m[4] {
+	case "s":
+		return t.Add(time.Duration(n) * time.Second), nil
+	case "m":
+		return t.Add(time.Duration(n) * time.Minute), nil
+	case "h":
+		return t.Add(time.Duration(n) * time.Hour), nil
+	case "d":
+		return t.AddDate(0, 0, n), nil
+	case "w":
+		return t.AddDate(0, 0, 7*n), nil
+	case "M":
+		return t.AddDate(0, n, 0), nil
+	case "y":
+		return t.AddDate(n, 0, 0), nil
+	}
+	return nil, errors.New("invalid template " + s)
+}
+
+func unmarshalJSON(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+func normalize(v interface{}) interface{} {
+	switch x := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(x))
+		for k, e := range x {
+			m[fmt.Sprint(k)] = normalize(e)
+		}
+		return m
+	case map[string]interface{}:
+		for k, e := range x {
+			x[k] = normalize(e)
+		}
+		return x
+	case []interface{}:
+		for i, e := range x {
+			x[i] = normalize(e)
+		}
+		return x
+	default:
+		return v
+	}
+}
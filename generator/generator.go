@@ -0,0 +1,191 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strings"
+
+	q "github.com/core-go/sql"
+	"github.com/core-go/sql/metadata"
+)
+
+type Config struct {
+	Package string `yaml:"package" mapstructure:"package" json:"package,omitempty"`
+	Driver  string `yaml:"driver" mapstructure:"driver" json:"driver,omitempty"`
+	Filter  bool   `yaml:"filter" mapstructure:"filter" json:"filter,omitempty"`
+	Adapter bool   `yaml:"adapter" mapstructure:"adapter" json:"adapter,omitempty"`
+}
+
+func Generate(c Config, tables ...metadata.Table) ([]byte, error) {
+	var body bytes.Buffer
+	imports := make(map[string]bool)
+	for _, table := range tables {
+		writeTable(&body, c, table, imports)
+	}
+	var buf bytes.Buffer
+	pkg := c.Package
+	if len(pkg) == 0 {
+		pkg = "model"
+	}
+	buf.WriteString("// Code generated by sqlgen. DO NOT EDIT.\n\n")
+	buf.WriteString("package " + pkg + "\n\n")
+	if len(imports) > 0 {
+		buf.WriteString("import (\n")
+		for _, path := range []string{"database/sql", "time"} {
+			if imports[path] {
+				buf.WriteString(fmt.Sprintf("\t%q\n", path))
+			}
+		}
+		buf.WriteString("\n")
+		for _, path := range []string{"github.com/core-go/search", "github.com/core-go/sql/adapter", "github.com/core-go/sql/query/builder"} {
+			if imports[path] {
+				buf.WriteString(fmt.Sprintf("\t%q\n", path))
+			}
+		}
+		buf.WriteString(")\n\n")
+	}
+	buf.Write(body.Bytes())
+	return format.Source(buf.Bytes())
+}
+
+func writeTable(buf *bytes.Buffer, c Config, table metadata.Table, imports map[string]bool) {
+	name := ToStructName(table.Name)
+	keys := 0
+	for _, col := range table.Columns {
+		if col.PrimaryKey {
+			keys++
+		}
+	}
+	keyType := "string"
+	buf.WriteString(fmt.Sprintf("type %s struct {\n", name))
+	for _, col := range table.Columns {
+		goType := GetGoType(col)
+		if strings.HasPrefix(goType, "time.") || strings.HasPrefix(goType, "*time.") {
+			imports["time"] = true
+		}
+		if col.PrimaryKey && keys == 1 {
+			keyType = goType
+		}
+		buf.WriteString(fmt.Sprintf("\t%s %s `%s`\n", ToFieldName(col.Name), goType, BuildTag(c.Driver, col, keys == 1)))
+	}
+	buf.WriteString("}\n\n")
+	if keys > 1 {
+		keyType = "map[string]interface{}"
+	}
+	if c.Filter || c.Adapter {
+		imports["github.com/core-go/search"] = true
+		buf.WriteString(fmt.Sprintf("type %sFilter struct {\n\t*search.Filter\n", name))
+		for _, col := range table.Columns {
+			goType := GetGoType(col)
+			filterType := strings.TrimPrefix(goType, "*")
+			switch filterType {
+			case "time.Time":
+				filterType = "*search.TimeRange"
+			case "int32", "int64", "float64":
+				filterType = "*search.NumberRange"
+			case "bool":
+				filterType = "*bool"
+			case "[]byte":
+				continue
+			}
+			buf.WriteString(fmt.Sprintf("\t%s %s `%s`\n", ToFieldName(col.Name), filterType, buildFilterTag(c.Driver, col)))
+		}
+		buf.WriteString("}\n\n")
+	}
+	if c.Adapter && len(table.Columns) > 0 {
+		imports["database/sql"] = true
+		imports["github.com/core-go/sql/adapter"] = true
+		imports["github.com/core-go/sql/query/builder"] = true
+		generic := fmt.Sprintf("%s, %s, *%sFilter", name, keyType, name)
+		buf.WriteString(fmt.Sprintf("func New%sAdapter(db *sql.DB) (*adapter.SearchAdapter[%s], error) {\n", name, generic))
+		buf.WriteString(fmt.Sprintf("\tbuildQuery := builder.UseQuery[%s, *%sFilter](db, %q)\n", name, name, table.Name))
+		buf.WriteString(fmt.Sprintf("\treturn adapter.NewSearchAdapter[%s](db, %q, buildQuery)\n}\n\n", generic, table.Name))
+	}
+}
+
+func GetGoType(col *metadata.Column) string {
+	t := strings.ToLower(col.Type)
+	var goType string
+	switch {
+	case strings.Contains(t, "bool") || t == "bit":
+		goType = "bool"
+	case strings.Contains(t, "bigint") || t == "int8" || t == "bigserial":
+		goType = "int64"
+	case strings.Contains(t, "int") && !strings.Contains(t, "interval") && !strings.Contains(t, "point"):
+		goType = "int32"
+	case t == "serial" || t == "smallserial":
+		goType = "int32"
+	case t == "number":
+		if col.Scale != nil && *col.Scale == 0 {
+			goType = "int64"
+		} else {
+			goType = "float64"
+		}
+	case strings.Contains(t, "decimal") || strings.Contains(t, "numeric") || strings.Contains(t, "money") || strings.Contains(t, "real") || strings.Contains(t, "double") || strings.Contains(t, "float"):
+		goType = "float64"
+	case strings.Contains(t, "date") || strings.Contains(t, "time"):
+		goType = "time.Time"
+	case strings.Contains(t, "blob") || strings.Contains(t, "binary") || t == "bytea" || t == "image" || t == "raw":
+		return "[]byte"
+	default:
+		goType = "string"
+	}
+	if col.Nullable && !col.PrimaryKey {
+		return "*" + goType
+	}
+	return goType
+}
+
+func BuildTag(driver string, col *metadata.Column, singleKey bool) string {
+	column := getColumnName(driver, col.Name)
+	json := q.ToCamelCase(column)
+	gorm := "column:" + column
+	bson := json
+	if col.PrimaryKey {
+		gorm = gorm + ";primary_key"
+		if singleKey {
+			bson = "_id"
+		}
+	}
+	tag := fmt.Sprintf(`yaml:"%s" mapstructure:"%s" json:"%s,omitempty" gorm:"%s" bson:"%s,omitempty" dynamodbav:"%s,omitempty" firestore:"%s,omitempty"`, column, column, json, gorm, bson, json, json)
+	if col.Scale != nil && *col.Scale > 0 && GetGoType(col) != "string" {
+		tag = tag + fmt.Sprintf(` scale:"%d"`, *col.Scale)
+	}
+	return tag
+}
+
+func buildFilterTag(driver string, col *metadata.Column) string {
+	column := getColumnName(driver, col.Name)
+	json := q.ToCamelCase(column)
+	return fmt.Sprintf(`yaml:"%s" mapstructure:"%s" json:"%s,omitempty" bson:"%s,omitempty" dynamodbav:"%s,omitempty" firestore:"%s,omitempty"`, column, column, json, json, json, json)
+}
+
+func getColumnName(driver string, name string) string {
+	if driver == q.DriverOracle {
+		return strings.ToLower(name)
+	}
+	return name
+}
+
+func ToFieldName(column string) string {
+	s := q.ToCamelCase(strings.ToLower(column))
+	if len(s) == 0 {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+func ToStructName(table string) string {
+	name := ToFieldName(table)
+	if strings.HasSuffix(name, "ies") {
+		return name[:len(name)-3] + "y"
+	}
+	if strings.HasSuffix(name, "sses") || strings.HasSuffix(name, "xes") {
+		return name[:len(name)-2]
+	}
+	if strings.HasSuffix(name, "s") && !strings.HasSuffix(name, "ss") {
+		return name[:len(name)-1]
+	}
+	return name
+}
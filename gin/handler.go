@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	q "github.com/core-go/sql"
+	"github.com/core-go/sql/export"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"strconv"
@@ -96,6 +97,10 @@ func (h *Handler) Exec(ctx *gin.Context) {
 		return
 	}
 	s.Params = q.ParseDates(s.Params, s.Dates)
+	if er0 = q.BindStatement(q.GetDriver(h.DB), &s); er0 != nil {
+		ctx.String(http.StatusBadRequest, er0.Error())
+		return
+	}
 	ps := r.URL.Query()
 	stx := ps.Get("tx")
 	if len(stx) == 0 {
@@ -154,6 +159,10 @@ func (h *Handler) Query(ctx *gin.Context) {
 		return
 	}
 	s.Params = q.ParseDates(s.Params, s.Dates)
+	if er0 = q.BindStatement(q.GetDriver(h.DB), &s); er0 != nil {
+		ctx.String(http.StatusBadRequest, er0.Error())
+		return
+	}
 	ps := r.URL.Query()
 	stx := ps.Get("tx")
 	if len(stx) == 0 {
@@ -199,6 +208,10 @@ func (h *Handler) QueryOne(ctx *gin.Context) {
 		return
 	}
 	s.Params = q.ParseDates(s.Params, s.Dates)
+	if er0 = q.BindStatement(q.GetDriver(h.DB), &s); er0 != nil {
+		ctx.String(http.StatusBadRequest, er0.Error())
+		return
+	}
 	ps := r.URL.Query()
 	stx := ps.Get("tx")
 	if len(stx) == 0 {
@@ -253,7 +266,12 @@ func (h *Handler) ExecBatch(ctx *gin.Context) {
 		return
 	}
 	l := len(s)
+	driver := q.GetDriver(h.DB)
 	for i := 0; i < l; i++ {
+		if er0 = q.BindStatement(driver, &s[i]); er0 != nil {
+			ctx.String(http.StatusBadRequest, er0.Error())
+			return
+		}
 		st := q.Statement{}
 		st.Query = s[i].Query
 		st.Params = q.ParseDates(s[i].Params, s[i].Dates)
@@ -297,6 +315,47 @@ func (h *Handler) ExecBatch(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, res)
 }
 
+func (h *Handler) Export(ctx *gin.Context) {
+	r := ctx.Request
+	s := q.JStatement{}
+	er0 := json.NewDecoder(r.Body).Decode(&s)
+	if er0 != nil {
+		ctx.String(http.StatusBadRequest, er0.Error())
+		return
+	}
+	s.Params = q.ParseDates(s.Params, s.Dates)
+	if er0 = q.BindStatement(q.GetDriver(h.DB), &s); er0 != nil {
+		ctx.String(http.StatusBadRequest, er0.Error())
+		return
+	}
+	ps := r.URL.Query()
+	format, filename, c := export.ParseConfig(ps)
+	var db q.Executor = h.DB
+	stx := ps.Get("tx")
+	if len(stx) > 0 {
+		tx, er0 := h.Cache.Get(stx)
+		if er0 != nil {
+			ctx.String(http.StatusInternalServerError, er0.Error())
+			return
+		}
+		if tx == nil {
+			ctx.String(http.StatusInternalServerError, "cannot get tx from cache. Maybe tx got timeout")
+			return
+		}
+		db = tx
+	}
+	rows, er1 := db.QueryContext(r.Context(), s.Query, s.Params...)
+	if er1 != nil {
+		handleError(ctx, 500, er1.Error(), h.Error, er1)
+		return
+	}
+	defer rows.Close()
+	_, er2 := export.Respond(r.Context(), ctx.Writer, rows, format, filename, h.Transform, c)
+	if er2 != nil && h.Error != nil {
+		h.Error(r.Context(), er2.Error())
+	}
+}
+
 func handleError(ctx *gin.Context, code int, result interface{}, logError func(context.Context, string, ...map[string]interface{}), err error) {
 	if logError != nil {
 		logError(ctx.Request.Context(), err.Error())
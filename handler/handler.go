@@ -9,6 +9,7 @@ import (
 	"time"
 
 	q "github.com/core-go/sql"
+	"github.com/core-go/sql/export"
 )
 
 type Handler struct {
@@ -93,6 +94,10 @@ func (h *Handler) Exec(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	s.Params = q.ParseDates(s.Params, s.Dates)
+	if er0 = q.BindStatement(q.GetDriver(h.DB), &s); er0 != nil {
+		http.Error(w, er0.Error(), http.StatusBadRequest)
+		return
+	}
 	ps := r.URL.Query()
 	stx := ps.Get("tx")
 	if len(stx) == 0 {
@@ -150,6 +155,10 @@ func (h *Handler) Query(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	s.Params = q.ParseDates(s.Params, s.Dates)
+	if er0 = q.BindStatement(q.GetDriver(h.DB), &s); er0 != nil {
+		http.Error(w, er0.Error(), http.StatusBadRequest)
+		return
+	}
 	ps := r.URL.Query()
 	stx := ps.Get("tx")
 	if len(stx) == 0 {
@@ -194,6 +203,10 @@ func (h *Handler) QueryOne(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	s.Params = q.ParseDates(s.Params, s.Dates)
+	if er0 = q.BindStatement(q.GetDriver(h.DB), &s); er0 != nil {
+		http.Error(w, er0.Error(), http.StatusBadRequest)
+		return
+	}
 	ps := r.URL.Query()
 	stx := ps.Get("tx")
 	if len(stx) == 0 {
@@ -247,7 +260,12 @@ func (h *Handler) ExecBatch(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	l := len(s)
+	driver := q.GetDriver(h.DB)
 	for i := 0; i < l; i++ {
+		if er0 = q.BindStatement(driver, &s[i]); er0 != nil {
+			http.Error(w, er0.Error(), http.StatusBadRequest)
+			return
+		}
 		st := q.Statement{}
 		st.Query = s[i].Query
 		st.Params = q.ParseDates(s[i].Params, s[i].Dates)
@@ -291,6 +309,46 @@ func (h *Handler) ExecBatch(w http.ResponseWriter, r *http.Request) {
 	respond(w, http.StatusOK, res)
 }
 
+func (h *Handler) Export(w http.ResponseWriter, r *http.Request) {
+	s := q.JStatement{}
+	er0 := json.NewDecoder(r.Body).Decode(&s)
+	if er0 != nil {
+		http.Error(w, er0.Error(), http.StatusBadRequest)
+		return
+	}
+	s.Params = q.ParseDates(s.Params, s.Dates)
+	if er0 = q.BindStatement(q.GetDriver(h.DB), &s); er0 != nil {
+		http.Error(w, er0.Error(), http.StatusBadRequest)
+		return
+	}
+	ps := r.URL.Query()
+	format, filename, c := export.ParseConfig(ps)
+	var db q.Executor = h.DB
+	stx := ps.Get("tx")
+	if len(stx) > 0 {
+		tx, er0 := h.Cache.Get(stx)
+		if er0 != nil {
+			http.Error(w, er0.Error(), http.StatusInternalServerError)
+			return
+		}
+		if tx == nil {
+			http.Error(w, "cannot get tx from cache. Maybe tx got timeout", http.StatusInternalServerError)
+			return
+		}
+		db = tx
+	}
+	rows, er1 := db.QueryContext(r.Context(), s.Query, s.Params...)
+	if er1 != nil {
+		handleError(w, r, 500, er1.Error(), h.Error, er1)
+		return
+	}
+	defer rows.Close()
+	_, er2 := export.Respond(r.Context(), w, rows, format, filename, h.Transform, c)
+	if er2 != nil && h.Error != nil {
+		h.Error(r.Context(), er2.Error())
+	}
+}
+
 func handleError(w http.ResponseWriter, r *http.Request, code int, result interface{}, logError func(context.Context, string, ...map[string]interface{}), err error) {
 	if logError != nil {
 		logError(r.Context(), err.Error())
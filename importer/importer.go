@@ -0,0 +1,374 @@
+package importer
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	q "github.com/core-go/sql"
+)
+
+const (
+	BestEffort   = 0
+	AllOrNothing = 1
+)
+
+var DefaultLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02", "02/01/2006"}
+
+type RowError struct {
+	Line    int    `yaml:"line" mapstructure:"line" json:"line,omitempty"`
+	Field   string `yaml:"field" mapstructure:"field" json:"field,omitempty"`
+	Message string `yaml:"message" mapstructure:"message" json:"message,omitempty"`
+}
+
+type Result struct {
+	Total    int        `yaml:"total" mapstructure:"total" json:"total"`
+	Accepted int        `yaml:"accepted" mapstructure:"accepted" json:"accepted"`
+	Rejected int        `yaml:"rejected" mapstructure:"rejected" json:"rejected"`
+	Errors   []RowError `yaml:"errors" mapstructure:"errors" json:"errors,omitempty"`
+}
+
+type column struct {
+	index    int
+	json     string
+	required bool
+	field    *q.FieldDB
+}
+
+type Importer[T any] struct {
+	DB         *sql.DB
+	Table      string
+	Schema     *q.Schema
+	Driver     string
+	BuildParam func(i int) string
+	Mode       int
+	Upsert     bool
+	BatchSize  int
+	Delimiter  rune
+	Layouts    []string
+	Map        func(*T)
+	Validate   func(context.Context, *T) error
+	ToArray    func(interface{}) interface {
+		driver.Valuer
+		sql.Scanner
+	}
+	columns  map[string]*column
+	required []*column
+}
+
+func NewImporter[T any](db *sql.DB, table string, mode int, options ...func(*T)) *Importer[T] {
+	return NewSqlImporter[T](db, table, mode, false, nil, options...)
+}
+func NewUpsertImporter[T any](db *sql.DB, table string, mode int, options ...func(*T)) *Importer[T] {
+	return NewSqlImporter[T](db, table, mode, true, nil, options...)
+}
+func NewSqlImporter[T any](db *sql.DB, table string, mode int, upsert bool, toArray func(interface{}) interface {
+	driver.Valuer
+	sql.Scanner
+}, options ...func(*T)) *Importer[T] {
+	var t T
+	modelType := reflect.TypeOf(t)
+	if modelType.Kind() != reflect.Struct {
+		panic("T must be a struct")
+	}
+	var mp func(*T)
+	if len(options) > 0 && options[0] != nil {
+		mp = options[0]
+	}
+	driver := q.GetDriver(db)
+	schema := q.CreateSchema(modelType, driver)
+	if upsert && len(schema.Keys) <= 0 {
+		panic(fmt.Sprintf("require primary key for table '%s'", table))
+	}
+	columns := make(map[string]*column)
+	required := make([]*column, 0)
+	for _, f := range schema.Columns {
+		field := modelType.Field(f.Index)
+		validate := field.Tag.Get("validate")
+		c := &column{index: f.Index, json: f.JSON, field: f, required: f.Key || strings.Contains(validate, "required")}
+		columns[strings.ToLower(f.JSON)] = c
+		columns[strings.ToLower(f.Column)] = c
+		columns[strings.ToLower(field.Name)] = c
+		if c.required {
+			required = append(required, c)
+		}
+	}
+	return &Importer[T]{DB: db, Table: table, Schema: schema, Driver: driver, BuildParam: q.GetBuildByDriver(driver), Mode: mode, Upsert: upsert,
+		BatchSize: 500, Delimiter: ',', Layouts: DefaultLayouts, Map: mp, ToArray: toArray, columns: columns, required: required}
+}
+
+// Import reads the CSV file, the first line is the header. In AllOrNothing mode, all chunks are written in one transaction, which is rolled back if any row is rejected.
+// In BestEffort mode, each chunk is written in its own transaction, and the rows of a failed chunk are retried one by one to find the rejected rows.
+func (im *Importer[T]) Import(ctx context.Context, reader io.Reader) (*Result, error) {
+	r := csv.NewReader(reader)
+	if im.Delimiter != 0 {
+		r.Comma = im.Delimiter
+	}
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	header, er0 := r.Read()
+	if er0 != nil {
+		return nil, er0
+	}
+	columns := make([]*column, len(header))
+	for i, h := range header {
+		columns[i] = im.columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))]
+	}
+	result := &Result{}
+	var tx *sql.Tx
+	if im.Mode == AllOrNothing {
+		var er1 error
+		tx, er1 = im.DB.BeginTx(ctx, nil)
+		if er1 != nil {
+			return nil, er1
+		}
+		defer tx.Rollback()
+	}
+	size := im.BatchSize
+	if size <= 0 {
+		size = 500
+	}
+	models := make([]T, 0, size)
+	lines := make([]int, 0, size)
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var pe *csv.ParseError
+			if errors.As(err, &pe) {
+				result.Total++
+				result.Errors = append(result.Errors, RowError{Line: pe.StartLine, Message: pe.Err.Error()})
+				continue
+			}
+			return result, err
+		}
+		if er2 := ctx.Err(); er2 != nil {
+			return result, er2
+		}
+		result.Total++
+		line, _ := r.FieldPos(0)
+		var model T
+		if errs := im.parse(ctx, line, columns, record, &model); len(errs) > 0 {
+			result.Errors = append(result.Errors, errs...)
+			continue
+		}
+		models = append(models, model)
+		lines = append(lines, line)
+		if len(models) >= size {
+			if er3 := im.write(ctx, tx, models, lines, result); er3 != nil {
+				return result, er3
+			}
+			models = models[:0]
+			lines = lines[:0]
+		}
+	}
+	if len(models) > 0 {
+		if er4 := im.write(ctx, tx, models, lines, result); er4 != nil {
+			return result, er4
+		}
+	}
+	result.Rejected = countLines(result.Errors)
+	if tx != nil {
+		if result.Rejected > 0 {
+			result.Accepted = 0
+			return result, nil
+		}
+		if er5 := tx.Commit(); er5 != nil {
+			return result, er5
+		}
+	}
+	result.Accepted = result.Total - result.Rejected
+	return result, nil
+}
+
+func (im *Importer[T]) parse(ctx context.Context, line int, columns []*column, record []string, model *T) []RowError {
+	var errs []RowError
+	if len(record) != len(columns) {
+		return append(errs, RowError{Line: line, Message: fmt.Sprintf("expected %d fields, got %d", len(columns), len(record))})
+	}
+	v := reflect.ValueOf(model).Elem()
+	filled := make(map[*column]bool)
+	for i, c := range columns {
+		if c == nil {
+			continue
+		}
+		s := strings.TrimSpace(record[i])
+		if len(s) == 0 {
+			continue
+		}
+		if err := im.set(v.Field(c.index), c.field, s); err != nil {
+			errs = append(errs, RowError{Line: line, Field: c.json, Message: err.Error()})
+			continue
+		}
+		filled[c] = true
+	}
+	for _, c := range im.required {
+		if !filled[c] {
+			errs = append(errs, RowError{Line: line, Field: c.json, Message: "required"})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	if im.Map != nil {
+		im.Map(model)
+	}
+	if im.Validate != nil {
+		if err := im.Validate(ctx, model); err != nil {
+			errs = append(errs, RowError{Line: line, Message: err.Error()})
+		}
+	}
+	return errs
+}
+
+func (im *Importer[T]) set(f reflect.Value, fdb *q.FieldDB, s string) error {
+	if f.Kind() == reflect.Ptr {
+		v := reflect.New(f.Type().Elem())
+		if err := im.set(v.Elem(), fdb, s); err != nil {
+			return err
+		}
+		f.Set(v)
+		return nil
+	}
+	if scanner, ok := f.Addr().Interface().(sql.Scanner); ok {
+		return scanner.Scan(s)
+	}
+	switch x := f.Addr().Interface().(type) {
+	case *time.Time:
+		for _, layout := range im.Layouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				*x = t
+				return nil
+			}
+		}
+		return fmt.Errorf("invalid date '%s'", s)
+	case *big.Float:
+		if _, ok := x.SetString(s); !ok {
+			return fmt.Errorf("invalid decimal '%s'", s)
+		}
+		return nil
+	case *big.Rat:
+		if _, ok := x.SetString(s); !ok {
+			return fmt.Errorf("invalid decimal '%s'", s)
+		}
+		return nil
+	case *big.Int:
+		if _, ok := x.SetString(s, 10); !ok {
+			return fmt.Errorf("invalid number '%s'", s)
+		}
+		return nil
+	}
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(s)
+	case reflect.Bool:
+		if fdb.True != nil && s == *fdb.True {
+			f.SetBool(true)
+		} else if fdb.False != nil && s == *fdb.False {
+			f.SetBool(false)
+		} else {
+			b, err := strconv.ParseBool(s)
+			if err != nil {
+				return fmt.Errorf("invalid boolean '%s'", s)
+			}
+			f.SetBool(b)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, f.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid integer '%s'", s)
+		}
+		f.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, err := strconv.ParseUint(s, 10, f.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid integer '%s'", s)
+		}
+		f.SetUint(i)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, f.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid decimal '%s'", s)
+		}
+		f.SetFloat(n)
+	default:
+		if err := json.Unmarshal([]byte(s), f.Addr().Interface()); err != nil {
+			return fmt.Errorf("invalid value '%s'", s)
+		}
+	}
+	return nil
+}
+
+func (im *Importer[T]) write(ctx context.Context, tx *sql.Tx, models []T, lines []int, result *Result) error {
+	if tx != nil {
+		if len(result.Errors) > 0 {
+			return nil
+		}
+		if err := im.exec(ctx, tx, models); err != nil {
+			for _, line := range lines {
+				result.Errors = append(result.Errors, RowError{Line: line, Message: err.Error()})
+			}
+		}
+		return nil
+	}
+	if err := im.execTx(ctx, models); err == nil {
+		return nil
+	}
+	for i := range models {
+		if err := im.execTx(ctx, models[i:i+1]); err != nil {
+			result.Errors = append(result.Errors, RowError{Line: lines[i], Message: err.Error()})
+		}
+	}
+	return ctx.Err()
+}
+func (im *Importer[T]) execTx(ctx context.Context, models []T) error {
+	tx, err := im.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err = im.exec(ctx, tx, models); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+func (im *Importer[T]) exec(ctx context.Context, tx *sql.Tx, models []T) error {
+	if !im.Upsert {
+		query, args, err := q.BuildToInsertBatchWithSchema(im.Table, models, im.Driver, im.ToArray, im.BuildParam, im.Schema)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, query, args...)
+		return err
+	}
+	for _, model := range models {
+		query, args, err := q.BuildToSaveWithSchema(im.Table, model, im.Driver, im.BuildParam, im.ToArray, im.Schema)
+		if err != nil {
+			return err
+		}
+		if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func countLines(errs []RowError) int {
+	lines := make(map[int]bool)
+	for _, e := range errs {
+		lines[e.Line] = true
+	}
+	return len(lines)
+}
@@ -0,0 +1,128 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"sync"
+)
+
+// ErrStop can be returned by the callback of Each to stop the iteration without error.
+var ErrStop = errors.New("stop iteration")
+
+var fieldsIndexes sync.Map
+
+// GetFieldsIndex returns the column indexes of the model type, computed once per type.
+func GetFieldsIndex(modelType reflect.Type) (map[string]int, error) {
+	if v, ok := fieldsIndexes.Load(modelType); ok {
+		return v.(map[string]int), nil
+	}
+	fieldsIndex, err := GetColumnIndexes(modelType)
+	if err != nil {
+		return nil, err
+	}
+	v, _ := fieldsIndexes.LoadOrStore(modelType, fieldsIndex)
+	return v.(map[string]int), nil
+}
+
+// Each scans the rows one by one into a new T, and calls fn for each row. The rows are not kept in memory.
+func Each[T any](ctx context.Context, db Executor, query string, args []interface{}, fn func(*T) error, options ...map[string]int) error {
+	var fieldsIndex map[string]int
+	if len(options) > 0 && options[0] != nil {
+		fieldsIndex = options[0]
+	}
+	return EachWithArray[T](ctx, db, fieldsIndex, nil, false, query, args, fn)
+}
+
+// EachWithArray is Each with the toArray function. If reuse is true, the same T is reset and scanned for each row, so fn must not keep the pointer.
+func EachWithArray[T any](ctx context.Context, db Executor, fieldsIndex map[string]int, toArray func(interface{}) interface {
+	driver.Valuer
+	sql.Scanner
+}, reuse bool, query string, args []interface{}, fn func(*T) error) error {
+	query, args = ExpandIn(query, ConvertArgs(args)...)
+	rows, er1 := db.QueryContext(ctx, query, args...)
+	if er1 != nil {
+		return er1
+	}
+	defer rows.Close()
+	er2 := EachRow[T](ctx, rows, fieldsIndex, toArray, reuse, fn)
+	if er2 == ErrStop {
+		er2 = nil
+	}
+	if er2 != nil {
+		return er2
+	}
+	return rows.Close()
+}
+
+// EachRow scans the rows, which must be closed by the caller. It returns ErrStop if fn stops the iteration.
+func EachRow[T any](ctx context.Context, rows *sql.Rows, fieldsIndex map[string]int, toArray func(interface{}) interface {
+	driver.Valuer
+	sql.Scanner
+}, reuse bool, fn func(*T) error) error {
+	var t T
+	modelType := reflect.TypeOf(t)
+	if fieldsIndex == nil {
+		var er0 error
+		fieldsIndex, er0 = GetFieldsIndex(modelType)
+		if er0 != nil {
+			return er0
+		}
+	}
+	columns, er1 := GetColumns(rows.Columns())
+	if er1 != nil {
+		return er1
+	}
+	var model *T
+	var r []interface{}
+	var swapValues map[int]interface{}
+	if reuse {
+		model = new(T)
+		r, swapValues = StructScanAndIgnore(model, columns, fieldsIndex, toArray, -1)
+	}
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if reuse {
+			*model = t
+		} else {
+			model = new(T)
+			r, swapValues = StructScanAndIgnore(model, columns, fieldsIndex, toArray, -1)
+		}
+		if err := rows.Scan(r...); err != nil {
+			return err
+		}
+		SwapValuesToBool(model, &swapValues)
+		if err := fn(model); err != nil {
+			return err
+		}
+	}
+	// Rows.Err will report the last error encountered by Rows.Next.
+	return rows.Err()
+}
+
+// Iterate returns the rows as a sequence of (T, error), which has the same signature as iter.Seq2[T, error].
+// If the query or a scan fails, the error is yielded once and the iteration stops.
+func Iterate[T any](ctx context.Context, db Executor, query string, args ...interface{}) func(yield func(T, error) bool) {
+	return IterateWithArray[T](ctx, db, nil, nil, query, args...)
+}
+func IterateWithArray[T any](ctx context.Context, db Executor, fieldsIndex map[string]int, toArray func(interface{}) interface {
+	driver.Valuer
+	sql.Scanner
+}, query string, args ...interface{}) func(yield func(T, error) bool) {
+	return func(yield func(T, error) bool) {
+		var t T
+		err := EachWithArray[T](ctx, db, fieldsIndex, toArray, true, query, args, func(model *T) error {
+			if !yield(*model, nil) {
+				return ErrStop
+			}
+			return nil
+		})
+		if err != nil {
+			yield(t, err)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package sql
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/core-go/sql/mock"
+)
+
+func TestEach(t *testing.T) {
+	db, rec := mock.New("postgres")
+	rec.ExpectRegexp("^select").WillReturnRows([]string{"id", "name"},
+		[]interface{}{"1", "a"}, []interface{}{"2", "b"}, []interface{}{"3", "c"})
+	var items []*testItem
+	err := Each[testItem](context.Background(), db, "select id, name from items where id in (?)", []interface{}{[]string{"1", "2", "3"}}, func(item *testItem) error {
+		items = append(items, item)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.AssertStatement(t, "select id, name from items where id in (?,?,?)", "1", "2", "3")
+	if len(items) != 3 || items[0].Id != "1" || items[2].Name != "c" || items[0] == items[1] {
+		t.Fatalf("expected 3 new items, got %v", items)
+	}
+}
+
+func TestEachStop(t *testing.T) {
+	db, rec := mock.New("postgres")
+	rec.ExpectRegexp("^select").WillReturnRows([]string{"id", "name"},
+		[]interface{}{"1", "a"}, []interface{}{"2", "b"}, []interface{}{"3", "c"})
+	n := 0
+	err := Each[testItem](context.Background(), db, "select id, name from items", nil, func(item *testItem) error {
+		n++
+		return ErrStop
+	})
+	if err != nil || n != 1 {
+		t.Fatalf("expected 1 row without error, got %d %v", n, err)
+	}
+	failed := errors.New("failed")
+	rec.ExpectRegexp("^select").WillReturnRows([]string{"id", "name"}, []interface{}{"1", "a"})
+	if err := Each[testItem](context.Background(), db, "select id, name from items", nil, func(item *testItem) error {
+		return failed
+	}); err != failed {
+		t.Fatalf("expected the error of the callback, got %v", err)
+	}
+}
+
+func TestEachWithArrayReuse(t *testing.T) {
+	db, rec := mock.New("mysql")
+	rec.ExpectRegexp("^select").WillReturnRows([]string{"id", "name"},
+		[]interface{}{"1", "a"}, []interface{}{"2", "b"})
+	var pointers []*testItem
+	var items []testItem
+	err := EachWithArray[testItem](context.Background(), db, nil, nil, true, "select id, name from items", nil, func(item *testItem) error {
+		pointers = append(pointers, item)
+		items = append(items, *item)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pointers[0] != pointers[1] {
+		t.Error("expected the same item to be reused")
+	}
+	if len(items) != 2 || items[0].Name != "a" || items[1].Id != "2" || items[1].Name != "b" {
+		t.Fatalf("expected the item to be scanned for each row, got %v", items)
+	}
+}
+
+func TestIterate(t *testing.T) {
+	db, rec := mock.New("postgres")
+	rec.ExpectRegexp("^select").WillReturnRows([]string{"id", "name"},
+		[]interface{}{"1", "a"}, []interface{}{"2", "b"}, []interface{}{"3", "c"})
+	var ids []string
+	Iterate[testItem](context.Background(), db, "select id, name from items")(func(item testItem, err error) bool {
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, item.Id)
+		return len(ids) < 2
+	})
+	if len(ids) != 2 || ids[0] != "1" || ids[1] != "2" {
+		t.Fatalf("expected to stop after 2 items, got %v", ids)
+	}
+
+	failed := errors.New("connection lost")
+	rec.ExpectRegexp("^select").WillReturnError(failed)
+	var errs []error
+	Iterate[testItem](context.Background(), db, "select id, name from items")(func(item testItem, err error) bool {
+		errs = append(errs, err)
+		return true
+	})
+	if len(errs) != 1 || errs[0] != failed {
+		t.Fatalf("expected the error once, got %v", errs)
+	}
+}
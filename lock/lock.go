@@ -0,0 +1,293 @@
+package lock
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	q "github.com/core-go/sql"
+)
+
+var (
+	ErrNotLocked = errors.New("lock is not held")
+	ErrLockLost  = errors.New("lock is lost")
+)
+
+// Locker creates the distributed locks by the database.
+// The advisory locks are used for Postgres (pg_try_advisory_lock), My SQL (GET_LOCK) and MS SQL (sp_getapplock), on a dedicated connection.
+// The lease table is used for SQLite and Oracle, or if Lease is true, such as:
+// create table locks (name varchar(255) primary key, owner varchar(64), token bigint, expiresat timestamp)
+// The leases expire by the clock of the application, so the clocks of the instances should be synchronized.
+type Locker struct {
+	db         *sql.DB
+	tableName  string
+	Driver     string
+	BuildParam func(i int) string
+	// Lease uses the lease table instead of the advisory locks. It is required for the fencing tokens
+	Lease bool
+	// TTL is the lease duration, which is renewed every TTL/3 while the lock is held
+	TTL time.Duration
+	// RetryInterval is the interval of TryLock in Lock
+	RetryInterval time.Duration
+	// OnError receives the errors of the background renewal, and of the release when the context of TryLock or Lock ends
+	OnError func(err error)
+}
+
+// NewLocker creates a Locker. options[0] is the lease table, "locks" by default.
+func NewLocker(db *sql.DB, options ...string) *Locker {
+	tableName := "locks"
+	if len(options) > 0 && len(options[0]) > 0 {
+		tableName = options[0]
+	}
+	driver := q.GetDriver(db)
+	lease := driver != q.DriverPostgres && driver != q.DriverMysql && driver != q.DriverMssql
+	return &Locker{db: db, tableName: tableName, Driver: driver, BuildParam: q.GetBuild(db), Lease: lease, TTL: 30 * time.Second, RetryInterval: time.Second}
+}
+
+// Lock is a held lock. Token is the fencing token of the lease, which increases every time the lock is acquired. It is 0 for the advisory locks.
+// The lock is released when Unlock is called, or when the context of TryLock or Lock ends.
+type Lock struct {
+	Name    string
+	Token   int64
+	owner   string
+	locker  *Locker
+	conn    *sql.Conn
+	mu      sync.Mutex
+	held    bool
+	lost    chan struct{}
+	stopped chan struct{}
+}
+
+// TryLock acquires the lock without waiting. It returns false if the lock is held by another owner.
+func (l *Locker) TryLock(ctx context.Context, name string) (*Lock, bool, error) {
+	k := &Lock{Name: name, locker: l, lost: make(chan struct{}), stopped: make(chan struct{})}
+	var ok bool
+	var err error
+	if l.Lease {
+		ok, err = l.tryLease(ctx, k)
+	} else {
+		ok, err = l.tryAdvisory(ctx, k)
+	}
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	k.held = true
+	go k.keep(ctx)
+	return k, true, nil
+}
+
+// Lock waits until the lock is acquired, or ctx is done.
+func (l *Locker) Lock(ctx context.Context, name string) (*Lock, error) {
+	for {
+		k, ok, err := l.TryLock(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return k, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(l.RetryInterval):
+		}
+	}
+}
+
+// Lost is closed when the lease cannot be renewed or the connection of the advisory lock is broken, so the lock may be held by another owner.
+func (k *Lock) Lost() <-chan struct{} {
+	return k.lost
+}
+
+// Unlock releases the lock. It returns ErrNotLocked if the lock is already released.
+// If the advisory lock cannot be released, the connection is discarded instead of being returned to the pool, so the lock is released with the session.
+func (k *Lock) Unlock(ctx context.Context) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if !k.held {
+		return ErrNotLocked
+	}
+	k.held = false
+	close(k.stopped)
+	l := k.locker
+	if l.Lease {
+		c := columns
+		query := fmt.Sprintf("update %s set %s = '', %s = %s where %s = %s and %s = %s and %s = %s", l.tableName, c.owner, c.expiresAt, l.BuildParam(1), c.name, l.BuildParam(2), c.owner, l.BuildParam(3), c.token, l.BuildParam(4))
+		_, err := l.db.ExecContext(ctx, query, time.Now(), k.Name, k.owner, k.Token)
+		return err
+	}
+	var query string
+	var args []interface{}
+	switch l.Driver {
+	case q.DriverPostgres:
+		query, args = "select pg_advisory_unlock($1)", []interface{}{advisoryKey(k.Name)}
+	case q.DriverMysql:
+		query, args = "select release_lock(?)", []interface{}{k.Name}
+	default:
+		query, args = "exec sp_releaseapplock @Resource = @p1, @LockOwner = 'Session'", []interface{}{k.Name}
+	}
+	_, err := k.conn.ExecContext(ctx, query, args...)
+	if err != nil {
+		k.conn.Raw(func(interface{}) error {
+			return driver.ErrBadConn
+		})
+	}
+	k.conn.Close()
+	return err
+}
+
+// Renew extends the lease for TTL. It returns ErrLockLost if the lease is expired and acquired by another owner. It is called automatically while the lock is held.
+func (k *Lock) Renew(ctx context.Context) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if !k.held {
+		return ErrNotLocked
+	}
+	l := k.locker
+	if !l.Lease {
+		return k.conn.PingContext(ctx)
+	}
+	c := columns
+	query := fmt.Sprintf("update %s set %s = %s where %s = %s and %s = %s and %s = %s", l.tableName, c.expiresAt, l.BuildParam(1), c.name, l.BuildParam(2), c.owner, l.BuildParam(3), c.token, l.BuildParam(4))
+	res, err := l.db.ExecContext(ctx, query, time.Now().Add(l.TTL), k.Name, k.owner, k.Token)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrLockLost
+	}
+	return nil
+}
+
+// keep renews the lock every TTL/3, and releases it when ctx ends.
+func (k *Lock) keep(ctx context.Context) {
+	interval := k.locker.TTL / 3
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-k.stopped:
+			return
+		case <-ctx.Done():
+			c, cancel := context.WithTimeout(context.Background(), interval)
+			err := k.Unlock(c)
+			cancel()
+			if err != nil && err != ErrNotLocked {
+				k.onError(err)
+			}
+			return
+		case <-ticker.C:
+			c, cancel := context.WithTimeout(context.Background(), interval)
+			err := k.Renew(c)
+			cancel()
+			if err != nil && err != ErrNotLocked {
+				k.onError(err)
+			}
+			if err == ErrLockLost || (err != nil && !k.locker.Lease) {
+				close(k.lost)
+				return
+			}
+		}
+	}
+}
+func (k *Lock) onError(err error) {
+	if k.locker.OnError != nil {
+		k.locker.OnError(err)
+	}
+}
+
+var columns = struct {
+	name      string
+	owner     string
+	token     string
+	expiresAt string
+}{name: "name", owner: "owner", token: "token", expiresAt: "expiresat"}
+
+// tryLease takes the lease if it is expired, or inserts it if it does not exist. The token is increased, and is never reset.
+func (l *Locker) tryLease(ctx context.Context, k *Lock) (bool, error) {
+	owner, err := newOwner()
+	if err != nil {
+		return false, err
+	}
+	k.owner = owner
+	now := time.Now()
+	c := columns
+	query := fmt.Sprintf("update %s set %s = %s, %s = %s + 1, %s = %s where %s = %s and %s < %s", l.tableName,
+		c.owner, l.BuildParam(1), c.token, c.token, c.expiresAt, l.BuildParam(2), c.name, l.BuildParam(3), c.expiresAt, l.BuildParam(4))
+	res, err := l.db.ExecContext(ctx, query, owner, now.Add(l.TTL), k.Name, now)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if n == 0 {
+		query = fmt.Sprintf("insert into %s (%s, %s, %s, %s) values (%s, %s, %s, %s)", l.tableName, c.name, c.owner, c.token, c.expiresAt,
+			l.BuildParam(1), l.BuildParam(2), l.BuildParam(3), l.BuildParam(4))
+		if _, err = l.db.ExecContext(ctx, query, k.Name, owner, 1, now.Add(l.TTL)); err != nil {
+			if q.ClassifyError(err) == q.ErrorDuplicate {
+				return false, nil
+			}
+			return false, err
+		}
+		k.Token = 1
+		return true, nil
+	}
+	query = fmt.Sprintf("select %s from %s where %s = %s and %s = %s", c.token, l.tableName, c.name, l.BuildParam(1), c.owner, l.BuildParam(2))
+	if err = l.db.QueryRowContext(ctx, query, k.Name, owner).Scan(&k.Token); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// tryAdvisory takes the advisory lock on a dedicated connection, because the lock belongs to the session.
+func (l *Locker) tryAdvisory(ctx context.Context, k *Lock) (bool, error) {
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return false, err
+	}
+	var ok bool
+	switch l.Driver {
+	case q.DriverPostgres:
+		err = conn.QueryRowContext(ctx, "select pg_try_advisory_lock($1)", advisoryKey(k.Name)).Scan(&ok)
+	case q.DriverMysql:
+		var res sql.NullInt64
+		err = conn.QueryRowContext(ctx, "select get_lock(?, 0)", k.Name).Scan(&res)
+		ok = res.Valid && res.Int64 == 1
+	default:
+		var res int64
+		err = conn.QueryRowContext(ctx, "declare @r int; exec @r = sp_getapplock @Resource = @p1, @LockMode = 'Exclusive', @LockOwner = 'Session', @LockTimeout = 0; select @r", k.Name).Scan(&res)
+		ok = res >= 0
+	}
+	if err != nil || !ok {
+		conn.Close()
+		return false, err
+	}
+	k.conn = conn
+	return true, nil
+}
+
+// advisoryKey hashes the name to the bigint key of pg_try_advisory_lock.
+func advisoryKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return int64(h.Sum64())
+}
+func newOwner() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
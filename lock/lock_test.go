@@ -0,0 +1,189 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/core-go/sql/mock"
+)
+
+func TestTryLockInsertsNewLease(t *testing.T) {
+	db, rec := mock.New("sqlite3")
+	rec.ExpectRegexp("^update locks set owner").WillReturnResult(0, 0)
+	l := NewLocker(db)
+	k, ok, err := l.TryLock(context.Background(), "job")
+	if err != nil || !ok {
+		t.Fatalf("expected the lock, got %v %v", ok, err)
+	}
+	if k.Token != 1 {
+		t.Errorf("expected the token 1 of the new lease, got %d", k.Token)
+	}
+	rec.AssertStatement(t, "update locks set owner = ?, token = token + 1, expiresat = ? where name = ? and expiresat < ?", k.owner, mock.AnyArg, "job", mock.AnyArg)
+	rec.AssertStatement(t, "insert into locks (name, owner, token, expiresat) values (?, ?, ?, ?)", "job", k.owner, 1, mock.AnyArg)
+	if err := k.Unlock(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	rec.AssertStatement(t, "update locks set owner = '', expiresat = ? where name = ? and owner = ? and token = ?", mock.AnyArg, "job", k.owner, int64(1))
+	if err := k.Unlock(context.Background()); err != ErrNotLocked {
+		t.Fatalf("expected ErrNotLocked, got %v", err)
+	}
+}
+
+func TestTryLockTakesExpiredLease(t *testing.T) {
+	db, rec := mock.New("oracle")
+	rec.ExpectRegexp("^select token").WillReturnRows([]string{"token"}, []interface{}{int64(5)})
+	l := NewLocker(db)
+	k, ok, err := l.TryLock(context.Background(), "job")
+	if err != nil || !ok || k.Token != 5 {
+		t.Fatalf("expected the lock with the token 5, got %v %v %v", k, ok, err)
+	}
+	rec.AssertStatement(t, "select token from locks where name = :1 and owner = :2", "job", k.owner)
+	if err := k.Renew(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	rec.AssertStatement(t, "update locks set expiresat = :1 where name = :2 and owner = :3 and token = :4", mock.AnyArg, "job", k.owner, int64(5))
+	rec.ExpectRegexp("^update locks set expiresat").WillReturnResult(0, 0)
+	if err := k.Renew(context.Background()); err != ErrLockLost {
+		t.Fatalf("expected ErrLockLost, got %v", err)
+	}
+	k.Unlock(context.Background())
+}
+
+func TestTryLockHeldLease(t *testing.T) {
+	db, rec := mock.New("sqlite3")
+	rec.ExpectRegexp("^update").WillReturnResult(0, 0)
+	rec.ExpectRegexp("^insert").WillReturnError(errors.New("UNIQUE constraint failed: locks.name"))
+	k, ok, err := NewLocker(db).TryLock(context.Background(), "job")
+	if k != nil || ok || err != nil {
+		t.Fatalf("expected the lock to be held by another owner, got %v %v %v", k, ok, err)
+	}
+}
+
+func TestLeaseLost(t *testing.T) {
+	db, rec := mock.New("sqlite3")
+	rec.ExpectRegexp("^select token").WillReturnRows([]string{"token"}, []interface{}{int64(2)})
+	rec.ExpectRegexp("^update locks set expiresat").WillReturnResult(0, 0)
+	l := NewLocker(db)
+	l.TTL = 30 * time.Millisecond
+	errs := make(chan error, 1)
+	l.OnError = func(err error) {
+		errs <- err
+	}
+	k, ok, err := l.TryLock(context.Background(), "job")
+	if err != nil || !ok {
+		t.Fatalf("expected the lock, got %v %v", ok, err)
+	}
+	select {
+	case <-k.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("expected the lock to be lost")
+	}
+	if err := <-errs; err != ErrLockLost {
+		t.Fatalf("expected ErrLockLost, got %v", err)
+	}
+}
+
+func TestLockIsReleasedWhenContextEnds(t *testing.T) {
+	db, rec := mock.New("sqlite3")
+	rec.ExpectRegexp("^select token").WillReturnRows([]string{"token"}, []interface{}{int64(2)})
+	ctx, cancel := context.WithCancel(context.Background())
+	k, ok, err := NewLocker(db).TryLock(ctx, "job")
+	if err != nil || !ok {
+		t.Fatalf("expected the lock, got %v %v", ok, err)
+	}
+	cancel()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		for _, s := range rec.Statements() {
+			if strings.HasPrefix(s.Query, "update locks set owner = ''") {
+				if err := k.Unlock(context.Background()); err != ErrNotLocked {
+					t.Fatalf("expected ErrNotLocked, got %v", err)
+				}
+				return
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("expected the lock to be released")
+}
+
+func TestAdvisoryLockByDialect(t *testing.T) {
+	tests := []struct {
+		dialect string
+		lock    string
+		result  interface{}
+		unlock  string
+		arg     interface{}
+	}{
+		{"postgres", "select pg_try_advisory_lock($1)", true, "select pg_advisory_unlock($1)", advisoryKey("job")},
+		{"mysql", "select get_lock(?, 0)", int64(1), "select release_lock(?)", "job"},
+		{"mssql", "declare @r int; exec @r = sp_getapplock @Resource = @p1, @LockMode = 'Exclusive', @LockOwner = 'Session', @LockTimeout = 0; select @r", int64(0), "exec sp_releaseapplock @Resource = @p1, @LockOwner = 'Session'", "job"},
+	}
+	for _, tt := range tests {
+		db, rec := mock.New(tt.dialect)
+		rec.Expect(tt.lock).WillReturnRows([]string{"r"}, []interface{}{tt.result})
+		k, ok, err := NewLocker(db).TryLock(context.Background(), "job")
+		if err != nil || !ok {
+			t.Fatalf("%s: expected the lock, got %v %v", tt.dialect, ok, err)
+		}
+		if k.Token != 0 {
+			t.Errorf("%s: expected no token of the advisory lock, got %d", tt.dialect, k.Token)
+		}
+		if err := k.Unlock(context.Background()); err != nil {
+			t.Fatalf("%s: %v", tt.dialect, err)
+		}
+		rec.AssertStatement(t, tt.lock, tt.arg)
+		rec.AssertStatement(t, tt.unlock, tt.arg)
+	}
+}
+
+func TestAdvisoryLockHeld(t *testing.T) {
+	tests := []struct {
+		dialect string
+		result  interface{}
+	}{
+		{"postgres", false},
+		{"mysql", int64(0)},
+		{"mysql", nil},
+		{"mssql", int64(-1)},
+	}
+	for _, tt := range tests {
+		db, rec := mock.New(tt.dialect)
+		rec.ExpectRegexp("lock").WillReturnRows([]string{"r"}, []interface{}{tt.result})
+		k, ok, err := NewLocker(db).TryLock(context.Background(), "job")
+		if k != nil || ok || err != nil {
+			t.Fatalf("%s: expected the lock to be held by another session, got %v %v %v", tt.dialect, k, ok, err)
+		}
+	}
+}
+
+func TestAdvisoryUnlockFails(t *testing.T) {
+	db, rec := mock.New("postgres")
+	failed := errors.New("connection reset")
+	rec.ExpectRegexp("pg_try_advisory_lock").WillReturnRows([]string{"r"}, []interface{}{true})
+	rec.ExpectRegexp("pg_advisory_unlock").WillReturnError(failed)
+	l := NewLocker(db)
+	errs := make(chan error, 1)
+	l.OnError = func(err error) {
+		errs <- err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	if _, ok, err := l.TryLock(ctx, "job"); err != nil || !ok {
+		t.Fatalf("expected the lock, got %v %v", ok, err)
+	}
+	cancel()
+	select {
+	case err := <-errs:
+		if err != failed {
+			t.Fatalf("expected the error of the release, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the error of the release to be reported")
+	}
+	if s := db.Stats(); s.OpenConnections != 0 {
+		t.Errorf("expected the connection of the lock to be discarded, got %d open connections", s.OpenConnections)
+	}
+}
@@ -0,0 +1,241 @@
+package metadata
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+
+	q "github.com/core-go/sql"
+)
+
+type Column struct {
+	Name       string `yaml:"name" mapstructure:"name" json:"name,omitempty"`
+	Type       string `yaml:"type" mapstructure:"type" json:"type,omitempty"`
+	Nullable   bool   `yaml:"nullable" mapstructure:"nullable" json:"nullable,omitempty"`
+	PrimaryKey bool   `yaml:"primary_key" mapstructure:"primary_key" json:"primaryKey,omitempty"`
+	Scale      *int   `yaml:"scale" mapstructure:"scale" json:"scale,omitempty"`
+}
+
+type Table struct {
+	Name    string    `yaml:"name" mapstructure:"name" json:"name,omitempty"`
+	Columns []*Column `yaml:"columns" mapstructure:"columns" json:"columns,omitempty"`
+}
+
+func GetTableNames(ctx context.Context, db *sql.DB, driver string) ([]string, error) {
+	var query string
+	switch driver {
+	case q.DriverPostgres:
+		query = "select table_name from information_schema.tables where table_schema = current_schema() and table_type = 'BASE TABLE' order by table_name"
+	case q.DriverMysql:
+		query = "select table_name from information_schema.tables where table_schema = database() and table_type = 'BASE TABLE' order by table_name"
+	case q.DriverMssql:
+		query = "select table_name from information_schema.tables where table_schema = schema_name() and table_type = 'BASE TABLE' order by table_name"
+	case q.DriverOracle:
+		query = "select table_name from user_tables order by table_name"
+	default:
+		query = "select name from sqlite_master where type = 'table' and name not like 'sqlite_%' order by name"
+	}
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	names := make([]string, 0)
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+func GetTables(ctx context.Context, db *sql.DB, driver string, names ...string) ([]Table, error) {
+	if len(names) == 0 {
+		var er0 error
+		names, er0 = GetTableNames(ctx, db, driver)
+		if er0 != nil {
+			return nil, er0
+		}
+	}
+	tables := make([]Table, 0)
+	for _, name := range names {
+		columns, err := GetColumns(ctx, db, driver, name)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, Table{Name: name, Columns: columns})
+	}
+	return tables, nil
+}
+
+func GetColumns(ctx context.Context, db *sql.DB, driver string, table string) ([]*Column, error) {
+	if driver == q.DriverSqlite3 {
+		return getSqliteColumns(ctx, db, table)
+	}
+	buildParam := q.GetBuildByDriver(driver)
+	var query, keyQuery string
+	switch driver {
+	case q.DriverOracle:
+		query = "select column_name, data_type, nullable, data_scale from user_tab_columns where table_name = " + buildParam(1) + " order by column_id"
+		keyQuery = `select cc.column_name from user_constraints c join user_cons_columns cc on c.constraint_name = cc.constraint_name
+			where c.constraint_type = 'P' and c.table_name = ` + buildParam(1) + " order by cc.position"
+	default:
+		schema := "current_schema()"
+		if driver == q.DriverMysql {
+			schema = "database()"
+		} else if driver == q.DriverMssql {
+			schema = "schema_name()"
+		}
+		query = "select column_name, data_type, is_nullable, numeric_scale from information_schema.columns where table_schema = " + schema + " and table_name = " + buildParam(1) + " order by ordinal_position"
+		keyQuery = `select k.column_name from information_schema.table_constraints t join information_schema.key_column_usage k
+			on t.constraint_name = k.constraint_name and t.table_schema = k.table_schema and t.table_name = k.table_name
+			where t.constraint_type = 'PRIMARY KEY' and t.table_schema = ` + schema + " and t.table_name = " + buildParam(1) + " order by k.ordinal_position"
+	}
+	rows, er1 := db.QueryContext(ctx, query, table)
+	if er1 != nil {
+		return nil, er1
+	}
+	defer rows.Close()
+	columns := make([]*Column, 0)
+	for rows.Next() {
+		var name, dataType, nullable string
+		var scale sql.NullInt64
+		if err := rows.Scan(&name, &dataType, &nullable, &scale); err != nil {
+			return nil, err
+		}
+		c := &Column{Name: name, Type: strings.ToLower(dataType), Nullable: nullable == "YES" || nullable == "Y"}
+		if scale.Valid {
+			s := int(scale.Int64)
+			c.Scale = &s
+		}
+		columns = append(columns, c)
+	}
+	if er2 := rows.Err(); er2 != nil {
+		return nil, er2
+	}
+	keys, er3 := db.QueryContext(ctx, keyQuery, table)
+	if er3 != nil {
+		return nil, er3
+	}
+	defer keys.Close()
+	for keys.Next() {
+		var name string
+		if err := keys.Scan(&name); err != nil {
+			return nil, err
+		}
+		for _, c := range columns {
+			if c.Name == name {
+				c.PrimaryKey = true
+				c.Nullable = false
+			}
+		}
+	}
+	return columns, keys.Err()
+}
+
+func getSqliteColumns(ctx context.Context, db *sql.DB, table string) ([]*Column, error) {
+	rows, err := db.QueryContext(ctx, `pragma table_info("`+strings.ReplaceAll(table, `"`, `""`)+`")`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns := make([]*Column, 0)
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, dataType string
+		var defaultValue sql.NullString
+		if err = rows.Scan(&cid, &name, &dataType, &notNull, &defaultValue, &pk); err != nil {
+			return nil, err
+		}
+		c := &Column{Name: name, Type: strings.ToLower(dataType), Nullable: notNull == 0 && pk == 0, PrimaryKey: pk > 0}
+		c.Type, c.Scale = splitScale(c.Type)
+		columns = append(columns, c)
+	}
+	return columns, rows.Err()
+}
+
+func splitScale(dataType string) (string, *int) {
+	i := strings.Index(dataType, "(")
+	if i < 0 {
+		return dataType, nil
+	}
+	t := strings.TrimSpace(dataType[:i])
+	args := strings.Split(strings.TrimSuffix(dataType[i+1:], ")"), ",")
+	if len(args) == 2 {
+		if s, err := strconv.Atoi(strings.TrimSpace(args[1])); err == nil {
+			return t, &s
+		}
+	}
+	return t, nil
+}
+
+type ForeignKey struct {
+	Column           string `yaml:"column" mapstructure:"column" json:"column,omitempty"`
+	ReferencedTable  string `yaml:"referenced_table" mapstructure:"referenced_table" json:"referencedTable,omitempty"`
+	ReferencedColumn string `yaml:"referenced_column" mapstructure:"referenced_column" json:"referencedColumn,omitempty"`
+}
+
+func GetForeignKeys(ctx context.Context, db *sql.DB, driver string, table string) ([]ForeignKey, error) {
+	var query string
+	buildParam := q.GetBuildByDriver(driver)
+	switch driver {
+	case q.DriverPostgres, q.DriverMssql:
+		schema := "current_schema()"
+		if driver == q.DriverMssql {
+			schema = "schema_name()"
+		}
+		query = `select k.column_name, r.table_name, r.column_name from information_schema.referential_constraints rc
+			join information_schema.key_column_usage k on rc.constraint_schema = k.constraint_schema and rc.constraint_name = k.constraint_name
+			join information_schema.key_column_usage r on rc.unique_constraint_schema = r.constraint_schema and rc.unique_constraint_name = r.constraint_name and k.ordinal_position = r.ordinal_position
+			where k.table_schema = ` + schema + " and k.table_name = " + buildParam(1)
+	case q.DriverMysql:
+		query = `select column_name, referenced_table_name, referenced_column_name from information_schema.key_column_usage
+			where table_schema = database() and table_name = ? and referenced_table_name is not null`
+	case q.DriverOracle:
+		query = `select cc.column_name, rc.table_name, rcc.column_name from user_constraints c
+			join user_cons_columns cc on c.constraint_name = cc.constraint_name
+			join user_constraints rc on c.r_constraint_name = rc.constraint_name
+			join user_cons_columns rcc on rc.constraint_name = rcc.constraint_name and cc.position = rcc.position
+			where c.constraint_type = 'R' and c.table_name = :1`
+	default:
+		return getSqliteForeignKeys(ctx, db, table)
+	}
+	rows, err := db.QueryContext(ctx, query, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	keys := make([]ForeignKey, 0)
+	for rows.Next() {
+		var k ForeignKey
+		if err = rows.Scan(&k.Column, &k.ReferencedTable, &k.ReferencedColumn); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+func getSqliteForeignKeys(ctx context.Context, db *sql.DB, table string) ([]ForeignKey, error) {
+	rows, err := db.QueryContext(ctx, `pragma foreign_key_list("`+strings.ReplaceAll(table, `"`, `""`)+`")`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	keys := make([]ForeignKey, 0)
+	for rows.Next() {
+		var id, seq int
+		var k ForeignKey
+		var to sql.NullString
+		var onUpdate, onDelete, match string
+		if err = rows.Scan(&id, &seq, &k.ReferencedTable, &k.Column, &to, &onUpdate, &onDelete, &match); err != nil {
+			return nil, err
+		}
+		k.ReferencedColumn = to.String
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
@@ -0,0 +1,201 @@
+package mock
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"sync"
+)
+
+const DriverName = "mock"
+
+var (
+	recorders   = make(map[string]*Recorder)
+	recordersMu sync.Mutex
+)
+
+func init() {
+	sql.Register(DriverName, &Driver{})
+	for _, dialect := range []string{"postgres", "mysql", "mssql", "oracle", "sqlite3"} {
+		sql.Register(DriverName+"_"+dialect, &Driver{dialect: dialect})
+	}
+}
+
+// GetRecorder returns the recorder of the databases opened by sql.Open("mock_postgres", name), creating it if needed.
+func GetRecorder(name string) *Recorder {
+	recordersMu.Lock()
+	defer recordersMu.Unlock()
+	r, ok := recorders[name]
+	if !ok {
+		r = NewRecorder()
+		recorders[name] = r
+	}
+	return r
+}
+
+type Driver struct {
+	dialect  string
+	recorder *Recorder
+}
+
+func (d *Driver) Dialect() string {
+	return d.dialect
+}
+func (d *Driver) Open(name string) (driver.Conn, error) {
+	r := d.recorder
+	if r == nil {
+		r = GetRecorder(name)
+	}
+	return &conn{recorder: r}, nil
+}
+
+type connector struct {
+	driver *Driver
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	return &conn{recorder: c.driver.recorder}, nil
+}
+func (c *connector) Driver() driver.Driver {
+	return c.driver
+}
+
+type conn struct {
+	recorder *Recorder
+	tx       bool
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return &stmt{conn: c, query: query}, nil
+}
+func (c *conn) Close() error {
+	return nil
+}
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if err := c.recorder.record(Begin, nil, false); err != nil {
+		return nil, err
+	}
+	c.tx = true
+	return &tx{conn: c}, nil
+}
+func (c *conn) CheckNamedValue(v *driver.NamedValue) error {
+	return nil
+}
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, err := c.recorder.match(query, toValues(args), c.tx)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return &result{rowsAffected: c.recorder.RowsAffected}, nil
+	}
+	if e.err != nil {
+		return nil, e.err
+	}
+	return &result{lastInsertId: e.lastInsertId, rowsAffected: e.rowsAffected}, nil
+}
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	e, err := c.recorder.match(query, toValues(args), c.tx)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return &rows{}, nil
+	}
+	if e.err != nil {
+		return nil, e.err
+	}
+	return &rows{columns: e.columns, values: e.rows}, nil
+}
+
+type stmt struct {
+	conn  *conn
+	query string
+}
+
+func (s *stmt) Close() error {
+	return nil
+}
+func (s *stmt) NumInput() int {
+	return -1
+}
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.conn.ExecContext(context.Background(), s.query, toNamedValues(args))
+}
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.conn.QueryContext(context.Background(), s.query, toNamedValues(args))
+}
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.conn.ExecContext(ctx, s.query, args)
+}
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.conn.QueryContext(ctx, s.query, args)
+}
+func (s *stmt) CheckNamedValue(v *driver.NamedValue) error {
+	return nil
+}
+
+type tx struct {
+	conn *conn
+}
+
+func (t *tx) Commit() error {
+	t.conn.tx = false
+	return t.conn.recorder.record(Commit, nil, true)
+}
+func (t *tx) Rollback() error {
+	t.conn.tx = false
+	return t.conn.recorder.record(Rollback, nil, true)
+}
+
+type result struct {
+	lastInsertId int64
+	rowsAffected int64
+}
+
+func (r *result) LastInsertId() (int64, error) {
+	return r.lastInsertId, nil
+}
+func (r *result) RowsAffected() (int64, error) {
+	return r.rowsAffected, nil
+}
+
+type rows struct {
+	columns []string
+	values  [][]driver.Value
+	i       int
+}
+
+func (r *rows) Columns() []string {
+	return r.columns
+}
+func (r *rows) Close() error {
+	return nil
+}
+func (r *rows) Next(dest []driver.Value) error {
+	if r.i >= len(r.values) {
+		return io.EOF
+	}
+	copy(dest, r.values[r.i])
+	r.i++
+	return nil
+}
+
+func toValues(args []driver.NamedValue) []interface{} {
+	values := make([]interface{}, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	return values
+}
+func toNamedValues(args []driver.Value) []driver.NamedValue {
+	values := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		values[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+	}
+	return values
+}
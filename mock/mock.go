@@ -0,0 +1,20 @@
+package mock
+
+import "database/sql"
+
+type Executor struct {
+	*sql.DB
+	*Recorder
+}
+
+// New opens a database that records the statements instead of executing them.
+// The dialect (postgres, mysql, mssql, oracle, sqlite3) is reported by GetDriver, so placeholders and paging are built for it.
+func New(dialect string) (*sql.DB, *Recorder) {
+	r := NewRecorder()
+	db := sql.OpenDB(&connector{driver: &Driver{dialect: dialect, recorder: r}})
+	return db, r
+}
+func NewExecutor(dialect string) *Executor {
+	db, r := New(dialect)
+	return &Executor{DB: db, Recorder: r}
+}
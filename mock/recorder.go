@@ -0,0 +1,243 @@
+package mock
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const (
+	Begin    = "BEGIN"
+	Commit   = "COMMIT"
+	Rollback = "ROLLBACK"
+)
+
+type anyArg struct{}
+
+// AnyArg matches any value of an expected argument.
+var AnyArg = anyArg{}
+
+type Statement struct {
+	Query string        `yaml:"query" mapstructure:"query" json:"query,omitempty"`
+	Args  []interface{} `yaml:"args" mapstructure:"args" json:"args,omitempty"`
+	Tx    bool          `yaml:"tx" mapstructure:"tx" json:"tx,omitempty"`
+}
+
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+type Expectation struct {
+	query        string
+	regexp       *regexp.Regexp
+	args         []interface{}
+	checkArgs    bool
+	columns      []string
+	rows         [][]driver.Value
+	lastInsertId int64
+	rowsAffected int64
+	err          error
+	times        int
+	count        int
+}
+
+func (e *Expectation) WithArgs(args ...interface{}) *Expectation {
+	e.args = args
+	e.checkArgs = true
+	return e
+}
+func (e *Expectation) WillReturnRows(columns []string, rows ...[]interface{}) *Expectation {
+	e.columns = columns
+	e.rows = make([][]driver.Value, 0, len(rows))
+	for _, row := range rows {
+		values := make([]driver.Value, len(row))
+		for i, v := range row {
+			if dv, err := driver.DefaultParameterConverter.ConvertValue(v); err == nil {
+				values[i] = dv
+			} else {
+				values[i] = v
+			}
+		}
+		e.rows = append(e.rows, values)
+	}
+	return e
+}
+func (e *Expectation) WillReturnResult(lastInsertId int64, rowsAffected int64) *Expectation {
+	e.lastInsertId = lastInsertId
+	e.rowsAffected = rowsAffected
+	return e
+}
+func (e *Expectation) WillReturnError(err error) *Expectation {
+	e.err = err
+	return e
+}
+
+// Times sets how many statements the expectation answers, 0 means unlimited.
+func (e *Expectation) Times(n int) *Expectation {
+	e.times = n
+	return e
+}
+func (e *Expectation) String() string {
+	if e.regexp != nil {
+		return e.regexp.String()
+	}
+	return e.query
+}
+
+func (e *Expectation) matches(query string, args []interface{}) bool {
+	if e.times > 0 && e.count >= e.times {
+		return false
+	}
+	if e.regexp != nil {
+		if !e.regexp.MatchString(query) {
+			return false
+		}
+	} else if normalize(e.query) != normalize(query) {
+		return false
+	}
+	if !e.checkArgs {
+		return true
+	}
+	return matchArgs(e.args, args)
+}
+
+type Recorder struct {
+	// Strict makes the unexpected statements fail instead of returning empty rows or RowsAffected.
+	Strict       bool
+	RowsAffected int64
+	mu           sync.Mutex
+	statements   []Statement
+	expectations []*Expectation
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{RowsAffected: 1}
+}
+
+func (r *Recorder) Expect(query string) *Expectation {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e := &Expectation{query: query, times: 1}
+	r.expectations = append(r.expectations, e)
+	return e
+}
+func (r *Recorder) ExpectRegexp(pattern string) *Expectation {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e := &Expectation{regexp: regexp.MustCompile(pattern), times: 1}
+	r.expectations = append(r.expectations, e)
+	return e
+}
+func (r *Recorder) Statements() []Statement {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	statements := make([]Statement, len(r.statements))
+	copy(statements, r.statements)
+	return statements
+}
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statements = nil
+	r.expectations = nil
+}
+
+// Verify returns an error if an expectation has not answered any statement.
+func (r *Recorder) Verify() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var missing []string
+	for _, e := range r.expectations {
+		if e.count == 0 || (e.times > 0 && e.count < e.times) {
+			missing = append(missing, e.String())
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("mock: expected statements were not executed: %s", strings.Join(missing, "; "))
+	}
+	return nil
+}
+func (r *Recorder) AssertExpectations(t TestingT) bool {
+	t.Helper()
+	if err := r.Verify(); err != nil {
+		t.Errorf("%s", err.Error())
+		return false
+	}
+	return true
+}
+
+// AssertStatement checks that the query has been executed, with the args if they are provided.
+func (r *Recorder) AssertStatement(t TestingT, query string, args ...interface{}) bool {
+	t.Helper()
+	for _, s := range r.Statements() {
+		if normalize(s.Query) == normalize(query) && (len(args) == 0 || matchArgs(args, s.Args)) {
+			return true
+		}
+	}
+	t.Errorf("mock: statement was not executed: %s %v", query, args)
+	return false
+}
+
+func (r *Recorder) record(query string, args []interface{}, tx bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statements = append(r.statements, Statement{Query: query, Args: args, Tx: tx})
+	return nil
+}
+func (r *Recorder) match(query string, args []interface{}, tx bool) (*Expectation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statements = append(r.statements, Statement{Query: query, Args: args, Tx: tx})
+	for _, e := range r.expectations {
+		if e.matches(query, args) {
+			e.count++
+			return e, nil
+		}
+	}
+	if r.Strict {
+		return nil, errors.New("mock: unexpected statement: " + query)
+	}
+	return nil, nil
+}
+
+func normalize(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
+func matchArgs(expected []interface{}, args []interface{}) bool {
+	if len(expected) != len(args) {
+		return false
+	}
+	for i, v := range expected {
+		if _, ok := v.(anyArg); ok {
+			continue
+		}
+		if !equal(v, args[i]) {
+			return false
+		}
+	}
+	return true
+}
+func equal(expected interface{}, actual interface{}) bool {
+	if reflect.DeepEqual(expected, actual) {
+		return true
+	}
+	e := reflect.ValueOf(expected)
+	a := reflect.ValueOf(actual)
+	if e.Kind() == reflect.Ptr && !e.IsNil() {
+		expected = e.Elem().Interface()
+	}
+	if a.Kind() == reflect.Ptr && !a.IsNil() {
+		actual = a.Elem().Interface()
+	}
+	if v, ok := actual.(driver.Valuer); ok {
+		if dv, err := v.Value(); err == nil {
+			actual = dv
+		}
+	}
+	return fmt.Sprint(expected) == fmt.Sprint(actual)
+}
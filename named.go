@@ -0,0 +1,232 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var ErrDatesWithNamed = errors.New("dates cannot be used with named parameters, because they are the indexes of the positional params")
+
+// BindNamed rewrites the named parameters :name, or @name for MS SQL, to the placeholders of the driver, and returns the args in the order of the placeholders.
+// The args are bound from a map with string keys, or from a struct by the json tags, the gorm columns or the field names.
+// For Postgres and MS SQL, a repeated name reuses the same placeholder. For the other drivers, the value is repeated.
+// The string literals, the quoted identifiers, the comments, the casts "::" and the variables "@@" are not changed,
+// nor the backslash escapes and the variables @name of My SQL, nor the dollar-quoted strings of Postgres.
+func BindNamed(driver string, query string, arg interface{}) (string, []interface{}, error) {
+	lookup, er0 := getNamedValues(arg)
+	if er0 != nil {
+		return query, nil, er0
+	}
+	buildParam := GetBuildByDriver(driver)
+	reuse := driver == DriverPostgres || driver == DriverMssql
+	positions := make(map[string]int)
+	args := make([]interface{}, 0)
+	var b strings.Builder
+	n := len(query)
+	for i := 0; i < n; i++ {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			backslash := (driver == DriverMysql && c != '`') || (driver == DriverPostgres && c == '\'' && isEscapeString(query, i))
+			j := skipQuoted(query, i, c, backslash)
+			b.WriteString(query[i:j])
+			i = j - 1
+		case c == '$' && driver == DriverPostgres && isDollarQuote(query, i):
+			j := skipDollarQuoted(query, i)
+			b.WriteString(query[i:j])
+			i = j - 1
+		case c == '-' && i+1 < n && query[i+1] == '-':
+			j := strings.IndexByte(query[i:], '\n')
+			if j < 0 {
+				j = n - i
+			}
+			b.WriteString(query[i : i+j])
+			i = i + j - 1
+		case c == '/' && i+1 < n && query[i+1] == '*':
+			j := strings.Index(query[i+2:], "*/")
+			if j < 0 {
+				j = n
+			} else {
+				j = i + 2 + j + 2
+			}
+			b.WriteString(query[i:j])
+			i = j - 1
+		case (c == ':' || c == '@') && i+1 < n && query[i+1] == c:
+			b.WriteString(query[i : i+2])
+			i++
+		case (c == ':' || (c == '@' && driver == DriverMssql)) && i+1 < n && isNameStart(query[i+1]) && (i == 0 || !isNamePart(query[i-1])):
+			j := i + 1
+			for j < n && isNamePart(query[j]) {
+				j++
+			}
+			name := query[i+1 : j]
+			v, ok := lookup(name)
+			if !ok {
+				return query, nil, fmt.Errorf("missing parameter '%s'", name)
+			}
+			if p, exist := positions[name]; exist && reuse {
+				b.WriteString(buildParam(p))
+			} else {
+				args = append(args, v)
+				positions[name] = len(args)
+				b.WriteString(buildParam(len(args)))
+			}
+			i = j - 1
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), args, nil
+}
+
+// skipQuoted returns the end of the quoted text at i. A quote is escaped by a double quote, or by a backslash if backslash is true, such as for My SQL.
+func skipQuoted(query string, i int, quote byte, backslash bool) int {
+	n := len(query)
+	for j := i + 1; j < n; j++ {
+		if backslash && query[j] == '\\' {
+			j++
+			continue
+		}
+		if query[j] == quote {
+			if j+1 < n && query[j+1] == quote {
+				j++
+				continue
+			}
+			return j + 1
+		}
+	}
+	return n
+}
+
+// isEscapeString returns true if the string literal at i is an escape string of Postgres, such as E'it\'s'.
+func isEscapeString(query string, i int) bool {
+	return i > 0 && (query[i-1] == 'E' || query[i-1] == 'e') && (i == 1 || !isNamePart(query[i-2]))
+}
+
+// isDollarQuote returns true if a dollar-quoted string of Postgres starts at i, such as $$text$$ or $tag$text$tag$, but not the placeholder $1.
+func isDollarQuote(query string, i int) bool {
+	if i > 0 && isNamePart(query[i-1]) {
+		return false
+	}
+	return dollarTagEnd(query, i) > 0
+}
+func dollarTagEnd(query string, i int) int {
+	j := i + 1
+	if j < len(query) && query[j] != '$' && !isNameStart(query[j]) {
+		return -1
+	}
+	for j < len(query) && isNamePart(query[j]) {
+		j++
+	}
+	if j < len(query) && query[j] == '$' {
+		return j + 1
+	}
+	return -1
+}
+func skipDollarQuoted(query string, i int) int {
+	j := dollarTagEnd(query, i)
+	k := strings.Index(query[j:], query[i:j])
+	if k < 0 {
+		return len(query)
+	}
+	return j + k + j - i
+}
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+func isNamePart(c byte) bool {
+	return isNameStart(c) || (c >= '0' && c <= '9')
+}
+func getNamedValues(arg interface{}) (func(string) (interface{}, bool), error) {
+	if m, ok := arg.(map[string]interface{}); ok {
+		return func(name string) (interface{}, bool) {
+			v, ok := m[name]
+			return v, ok
+		}, nil
+	}
+	v := reflect.Indirect(reflect.ValueOf(arg))
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, errors.New("the keys of the map must be string")
+		}
+		return func(name string) (interface{}, bool) {
+			x := v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+			if !x.IsValid() {
+				return nil, false
+			}
+			return x.Interface(), true
+		}, nil
+	case reflect.Struct:
+		modelType := v.Type()
+		indexes := make(map[string]int)
+		for i := 0; i < modelType.NumField(); i++ {
+			field := modelType.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			indexes[strings.ToLower(field.Name)] = i
+			if column, ok := FindTag(field.Tag.Get("gorm"), "column"); ok {
+				indexes[strings.ToLower(column)] = i
+			}
+			if tag, ok := field.Tag.Lookup("json"); ok {
+				if name := strings.Split(tag, ",")[0]; len(name) > 0 && name != "-" {
+					indexes[strings.ToLower(name)] = i
+				}
+			}
+		}
+		return func(name string) (interface{}, bool) {
+			i, ok := indexes[strings.ToLower(name)]
+			if !ok {
+				return nil, false
+			}
+			return v.Field(i).Interface(), true
+		}, nil
+	default:
+		return nil, errors.New("the named parameters must be a map or a struct")
+	}
+}
+
+func ExecNamed(ctx context.Context, db *sql.DB, query string, arg interface{}) (int64, error) {
+	query2, args, err := BindNamed(GetDriver(db), query, arg)
+	if err != nil {
+		return -1, err
+	}
+	return Exec(ctx, GetExec(ctx, db), query2, args...)
+}
+func QueryNamed(ctx context.Context, db *sql.DB, fieldsIndex map[string]int, results interface{}, query string, arg interface{}) error {
+	query2, args, err := BindNamed(GetDriver(db), query, arg)
+	if err != nil {
+		return err
+	}
+	return Query(ctx, GetExec(ctx, db), fieldsIndex, results, query2, args...)
+}
+func QueryMapNamed(ctx context.Context, db *sql.DB, transform func(s string) string, query string, arg interface{}) ([]map[string]interface{}, error) {
+	query2, args, err := BindNamed(GetDriver(db), query, arg)
+	if err != nil {
+		return nil, err
+	}
+	return QueryMap(ctx, GetExec(ctx, db), transform, query2, args...)
+}
+
+// BindStatement binds the named parameters of the statement, if any. The positional params are replaced.
+// Dates cannot be used with Named, because the indexes of Dates refer to the positional params.
+func BindStatement(driver string, s *JStatement) error {
+	if len(s.Named) == 0 {
+		return nil
+	}
+	if len(s.Dates) > 0 {
+		return ErrDatesWithNamed
+	}
+	query, args, err := BindNamed(driver, s.Query, s.Named)
+	if err != nil {
+		return err
+	}
+	s.Query = query
+	s.Params = args
+	return nil
+}
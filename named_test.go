@@ -0,0 +1,61 @@
+package sql
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBindNamed(t *testing.T) {
+	arg := map[string]interface{}{"id": 1, "name": "a"}
+	tests := []struct {
+		driver string
+		query  string
+		bound  string
+		args   []interface{}
+	}{
+		{DriverPostgres, "select * from users where id = :id or parent = :id and name = :name", "select * from users where id = $1 or parent = $1 and name = $2", []interface{}{1, "a"}},
+		{DriverMysql, "select * from users where id = :id or parent = :id", "select * from users where id = ? or parent = ?", []interface{}{1, 1}},
+		{DriverMssql, "select * from users where id = @id and name = :name", "select * from users where id = @p1 and name = @p2", []interface{}{1, "a"}},
+		{DriverOracle, "select * from users where id = :id", "select * from users where id = :1", []interface{}{1}},
+		{DriverPostgres, "select ':id', \":id\", x::text from users -- :id\n where id = :id /* :name */", "select ':id', \":id\", x::text from users -- :id\n where id = $1 /* :name */", []interface{}{1}},
+		{DriverMysql, "select 'it\\'s :name', `:id` from users where id = :id", "select 'it\\'s :name', `:id` from users where id = ?", []interface{}{1}},
+		{DriverMysql, "select @rownum := @rownum + 1, @@version from users where id = :id", "select @rownum := @rownum + 1, @@version from users where id = ?", []interface{}{1}},
+		{DriverPostgres, "select $$ it's :name $$, $fn$ :id $fn$ from users where id = :id", "select $$ it's :name $$, $fn$ :id $fn$ from users where id = $1", []interface{}{1}},
+		{DriverPostgres, "select E'it\\'s :name', 'a\\' from users where id = :id", "select E'it\\'s :name', 'a\\' from users where id = $1", []interface{}{1}},
+		{DriverMssql, "select 'it''s :name', @@rowcount where id = @id", "select 'it''s :name', @@rowcount where id = @p1", []interface{}{1}},
+	}
+	for _, tt := range tests {
+		bound, args, err := BindNamed(tt.driver, tt.query, arg)
+		if err != nil {
+			t.Errorf("%s %s: %v", tt.driver, tt.query, err)
+			continue
+		}
+		if bound != tt.bound || !reflect.DeepEqual(args, tt.args) {
+			t.Errorf("%s %s: expected %s %v, got %s %v", tt.driver, tt.query, tt.bound, tt.args, bound, args)
+		}
+	}
+}
+
+func TestBindNamedStruct(t *testing.T) {
+	type user struct {
+		Id   string `json:"id" gorm:"column:userid"`
+		Name string
+	}
+	bound, args, err := BindNamed(DriverPostgres, "update users set name = :name where userid = :userid or id = :id", user{Id: "1", Name: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bound != "update users set name = $1 where userid = $2 or id = $3" || !reflect.DeepEqual(args, []interface{}{"a", "1", "1"}) {
+		t.Errorf("unexpected %s %v", bound, args)
+	}
+	if _, _, err := BindNamed(DriverPostgres, "select :missing", user{}); err == nil {
+		t.Error("expected the error of the missing parameter")
+	}
+}
+
+func TestBindStatementWithDates(t *testing.T) {
+	s := &JStatement{Query: "select :id", Named: map[string]interface{}{"id": 1}, Dates: []int{0}}
+	if err := BindStatement(DriverPostgres, s); err != ErrDatesWithNamed {
+		t.Fatalf("expected ErrDatesWithNamed, got %v", err)
+	}
+}
@@ -0,0 +1,337 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+const NestedSeparator = "__"
+
+var (
+	nodes       sync.Map
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	timeType    = reflect.TypeOf(time.Time{})
+)
+
+type nestedField struct {
+	index int
+	ptr   bool
+	node  *nestedNode
+}
+type nestedNode struct {
+	modelType reflect.Type
+	keys      []int
+	structs   []nestedField
+	slices    []nestedField
+	paths     map[string]nestedPath
+}
+type nestedPath struct {
+	node  *nestedNode
+	index int
+}
+
+// getNode returns the mapping of the struct: the fields by gorm column, the nested struct fields and the one-to-many slice fields.
+// The columns of a nested field are prefixed by its name, such as customer__name or customer.name. The name is the "prefix" of the gorm tag, the json name or the lower case field name.
+func getNode(modelType reflect.Type) *nestedNode {
+	if v, ok := nodes.Load(modelType); ok {
+		return v.(*nestedNode)
+	}
+	n := buildNode(modelType, make(map[reflect.Type]bool))
+	v, _ := nodes.LoadOrStore(modelType, n)
+	return v.(*nestedNode)
+}
+func buildNode(modelType reflect.Type, visiting map[reflect.Type]bool) *nestedNode {
+	visiting[modelType] = true
+	defer delete(visiting, modelType)
+	n := &nestedNode{modelType: modelType, paths: make(map[string]nestedPath)}
+	for _, k := range CreateSchema(modelType).Keys {
+		n.keys = append(n.keys, k.Index)
+	}
+	for i := 0; i < modelType.NumField(); i++ {
+		field := modelType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		ormTag := field.Tag.Get("gorm")
+		if column, ok := FindTag(ormTag, "column"); ok {
+			column = strings.ToLower(column)
+			n.paths[column] = nestedPath{node: n, index: i}
+			continue
+		}
+		t := field.Type
+		isSlice := false
+		if t.Kind() == reflect.Slice {
+			isSlice = true
+			t = t.Elem()
+		}
+		isPtr := t.Kind() == reflect.Ptr
+		if isPtr {
+			t = t.Elem()
+		}
+		if !isNestedType(t) || visiting[t] {
+			continue
+		}
+		child := buildNode(t, visiting)
+		if len(child.paths) == 0 {
+			continue
+		}
+		f := nestedField{index: i, ptr: isPtr, node: child}
+		if isSlice {
+			n.slices = append(n.slices, f)
+		} else {
+			n.structs = append(n.structs, f)
+		}
+		prefix := getPrefix(field, ormTag)
+		for column, path := range child.paths {
+			n.paths[prefix+NestedSeparator+column] = path
+			n.paths[prefix+"."+column] = path
+		}
+	}
+	return n
+}
+func isNestedType(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct || t == timeType {
+		return false
+	}
+	return !reflect.PtrTo(t).Implements(scannerType)
+}
+func getPrefix(field reflect.StructField, ormTag string) string {
+	if prefix, ok := FindTag(ormTag, "prefix"); ok {
+		return strings.ToLower(prefix)
+	}
+	if tag, ok := field.Tag.Lookup("json"); ok {
+		name := strings.Split(tag, ",")[0]
+		if len(name) > 0 && name != "-" {
+			return strings.ToLower(name)
+		}
+	}
+	return strings.ToLower(field.Name)
+}
+
+type nestedBinding struct {
+	index  int
+	holder reflect.Value
+	ptr    bool
+	isTrue *string
+}
+type nestedScanner struct {
+	holders  []interface{}
+	bindings map[*nestedNode][]nestedBinding
+}
+
+func newNestedScanner(root *nestedNode, columns []string) *nestedScanner {
+	s := &nestedScanner{holders: make([]interface{}, len(columns)), bindings: make(map[*nestedNode][]nestedBinding)}
+	for i, column := range columns {
+		path, ok := root.paths[strings.ToLower(column)]
+		if !ok {
+			var t interface{}
+			s.holders[i] = &t
+			continue
+		}
+		field := path.node.modelType.Field(path.index)
+		b := nestedBinding{index: path.index}
+		t := field.Type
+		if t.Kind() == reflect.Ptr {
+			b.ptr = true
+			t = t.Elem()
+		}
+		if tagTrue, ok := field.Tag.Lookup("true"); ok && t.Kind() == reflect.Bool {
+			b.isTrue = &tagTrue
+			t = reflect.TypeOf("")
+		}
+		b.holder = reflect.New(reflect.PtrTo(t))
+		holderField := field
+		holderField.Type = reflect.PtrTo(t)
+		s.holders[i], _ = ToScanner(holderField, b.holder.Interface())
+		s.bindings[path.node] = append(s.bindings[path.node], b)
+	}
+	return s
+}
+
+// read creates the struct of the node from the current row. It returns false if all the columns of the node and of its nested structs are null.
+func (s *nestedScanner) read(n *nestedNode) (reflect.Value, bool) {
+	v := reflect.New(n.modelType)
+	e := v.Elem()
+	valid := false
+	for _, b := range s.bindings[n] {
+		p := b.holder.Elem()
+		if p.IsNil() {
+			continue
+		}
+		valid = true
+		if b.isTrue != nil {
+			x := p.Elem().String()
+			isTrue := x == *b.isTrue || x == "true"
+			p = reflect.ValueOf(&isTrue)
+		}
+		if b.ptr {
+			e.Field(b.index).Set(p)
+		} else {
+			e.Field(b.index).Set(p.Elem())
+		}
+	}
+	for _, f := range n.structs {
+		c, ok := s.read(f.node)
+		if !ok {
+			continue
+		}
+		valid = true
+		if f.ptr {
+			e.Field(f.index).Set(c)
+		} else {
+			e.Field(f.index).Set(c.Elem())
+		}
+	}
+	return v, valid
+}
+
+// key returns the primary key of the node in the current row. If the node has no primary key, all its columns are used.
+func (s *nestedScanner) key(n *nestedNode) string {
+	bindings := s.bindings[n]
+	values := make([]string, 0, len(bindings))
+	for _, k := range n.keys {
+		for _, b := range bindings {
+			if b.index == k {
+				values = append(values, b.string())
+			}
+		}
+	}
+	if len(n.keys) == 0 || len(values) < len(n.keys) {
+		values = values[:0]
+		for _, b := range bindings {
+			values = append(values, b.string())
+		}
+	}
+	return strings.Join(values, "\x00")
+}
+func (b nestedBinding) string() string {
+	p := b.holder.Elem()
+	if p.IsNil() {
+		return ""
+	}
+	return fmt.Sprint(p.Elem().Interface())
+}
+
+type nestedEntry struct {
+	value    reflect.Value
+	children [][]*nestedEntry
+	indexes  []map[string]*nestedEntry
+}
+
+func (s *nestedScanner) merge(n *nestedNode, entries []*nestedEntry, index map[string]*nestedEntry, root bool) []*nestedEntry {
+	v, valid := s.read(n)
+	if !valid && !root {
+		return entries
+	}
+	var e *nestedEntry
+	if index != nil {
+		k := s.key(n)
+		e = index[k]
+		if e == nil {
+			e = &nestedEntry{value: v}
+			index[k] = e
+			entries = append(entries, e)
+		}
+	} else {
+		e = &nestedEntry{value: v}
+		entries = append(entries, e)
+	}
+	if e.children == nil {
+		e.children = make([][]*nestedEntry, len(n.slices))
+		e.indexes = make([]map[string]*nestedEntry, len(n.slices))
+		if index != nil {
+			for i := range n.slices {
+				e.indexes[i] = make(map[string]*nestedEntry)
+			}
+		}
+	}
+	for i, f := range n.slices {
+		e.children[i] = s.merge(f.node, e.children[i], e.indexes[i], false)
+	}
+	return entries
+}
+func (e *nestedEntry) build(n *nestedNode) reflect.Value {
+	v := e.value.Elem()
+	for i, f := range n.slices {
+		field := v.Field(f.index)
+		slice := reflect.MakeSlice(field.Type(), 0, len(e.children[i]))
+		for _, c := range e.children[i] {
+			x := c.build(f.node)
+			if f.ptr {
+				slice = reflect.Append(slice, x.Addr())
+			} else {
+				slice = reflect.Append(slice, x)
+			}
+		}
+		field.Set(slice)
+	}
+	return v
+}
+
+func scanNested[T any](rows *sql.Rows, graph bool) ([]T, error) {
+	var t T
+	modelType := reflect.TypeOf(t)
+	if modelType.Kind() != reflect.Struct {
+		return nil, errors.New("T must be a struct")
+	}
+	n := getNode(modelType)
+	if graph && len(n.keys) == 0 {
+		return nil, fmt.Errorf("require primary key of %s", modelType.Name())
+	}
+	columns, er1 := rows.Columns()
+	if er1 != nil {
+		return nil, er1
+	}
+	s := newNestedScanner(n, columns)
+	var index map[string]*nestedEntry
+	if graph {
+		index = make(map[string]*nestedEntry)
+	}
+	var entries []*nestedEntry
+	for rows.Next() {
+		if err := rows.Scan(s.holders...); err != nil {
+			return nil, err
+		}
+		entries = s.merge(n, entries, index, true)
+	}
+	if er2 := rows.Err(); er2 != nil {
+		return nil, er2
+	}
+	objs := make([]T, len(entries))
+	for i, e := range entries {
+		objs[i] = e.build(n).Interface().(T)
+	}
+	return objs, nil
+}
+
+// ScanNested scans each row into a T, including the nested struct fields. A nested pointer is nil if all its columns are null.
+func ScanNested[T any](rows *sql.Rows) ([]T, error) {
+	return scanNested[T](rows, false)
+}
+
+// ScanGraph scans the joined rows in a single pass. The rows are grouped by the primary key of T, and the child rows are appended to the slice fields, without duplicates by the primary key of the child.
+func ScanGraph[T any](rows *sql.Rows) ([]T, error) {
+	return scanNested[T](rows, true)
+}
+func QueryNested[T any](ctx context.Context, db Executor, query string, args ...interface{}) ([]T, error) {
+	rows, err := UseTx(ctx, db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return ScanNested[T](rows)
+}
+func QueryGraph[T any](ctx context.Context, db Executor, query string, args ...interface{}) ([]T, error) {
+	rows, err := UseTx(ctx, db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return ScanGraph[T](rows)
+}
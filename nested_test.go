@@ -0,0 +1,89 @@
+package sql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/core-go/sql/mock"
+)
+
+type testCustomer struct {
+	Id   string `gorm:"column:id;primary_key"`
+	Name string `gorm:"column:name"`
+}
+type testLine struct {
+	Id       string    `gorm:"column:id;primary_key"`
+	Quantity int       `gorm:"column:quantity"`
+	Tags     []testTag `json:"tags"`
+}
+type testInvoice struct {
+	Id       string        `gorm:"column:id;primary_key"`
+	Active   bool          `gorm:"column:active" true:"Y"`
+	Customer *testCustomer `json:"customer"`
+	Lines    []testLine    `gorm:"prefix:items"`
+}
+
+var invoiceColumns = []string{"id", "active", "customer__id", "customer__name", "items__id", "items__quantity", "items__tags__id", "items__tags__name"}
+
+func TestQueryGraph(t *testing.T) {
+	db, rec := mock.New("postgres")
+	rec.ExpectRegexp("^select").WillReturnRows(invoiceColumns,
+		[]interface{}{"1", "Y", "c1", "C", "l1", int64(2), "t1", "T1"},
+		[]interface{}{"1", "Y", "c1", "C", "l1", int64(2), "t2", "T2"},
+		[]interface{}{"2", "N", nil, nil, nil, nil, nil, nil},
+		[]interface{}{"1", "Y", "c1", "C", "l2", int64(1), "t1", "T1"},
+		[]interface{}{"1", "Y", "c1", "C", "l1", int64(2), "t2", "T2"})
+	invoices, err := QueryGraph[testInvoice](context.Background(), db, "select * from invoices")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(invoices) != 2 {
+		t.Fatalf("expected 2 invoices, got %v", invoices)
+	}
+	i1, i2 := invoices[0], invoices[1]
+	if i1.Id != "1" || !i1.Active || i1.Customer == nil || i1.Customer.Name != "C" {
+		t.Errorf("unexpected invoice 1 %v", i1)
+	}
+	if len(i1.Lines) != 2 || i1.Lines[0].Id != "l1" || i1.Lines[0].Quantity != 2 || i1.Lines[1].Id != "l2" {
+		t.Fatalf("expected the lines l1 and l2 once, got %v", i1.Lines)
+	}
+	if tags := i1.Lines[0].Tags; len(tags) != 2 || tags[0].Id != "t1" || tags[1].Id != "t2" {
+		t.Errorf("expected the tags t1 and t2 of l1 once, got %v", tags)
+	}
+	if tags := i1.Lines[1].Tags; len(tags) != 1 || tags[0].Name != "T1" {
+		t.Errorf("expected the tag t1 of l2, got %v", tags)
+	}
+	if i2.Id != "2" || i2.Active || i2.Customer != nil || len(i2.Lines) != 0 {
+		t.Errorf("expected invoice 2 without customer and lines, got %v", i2)
+	}
+}
+
+func TestQueryNested(t *testing.T) {
+	db, rec := mock.New("mysql")
+	rec.ExpectRegexp("^select").WillReturnRows([]string{"id", "customer.id", "customer.name", "unknown"},
+		[]interface{}{"1", "c1", "C", "x"},
+		[]interface{}{"1", "c1", "C", "x"},
+		[]interface{}{"2", nil, nil, "x"})
+	invoices, err := QueryNested[testInvoice](context.Background(), db, "select * from invoices")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(invoices) != 3 {
+		t.Fatalf("expected a struct per row, got %v", invoices)
+	}
+	if invoices[1].Customer == nil || invoices[1].Customer.Id != "c1" || invoices[2].Customer != nil {
+		t.Errorf("expected the customer of the first 2 rows only, got %v", invoices)
+	}
+}
+
+func TestQueryGraphRequiresPrimaryKey(t *testing.T) {
+	type noKey struct {
+		Name  string     `gorm:"column:name"`
+		Lines []testLine `json:"lines"`
+	}
+	db, rec := mock.New("postgres")
+	rec.ExpectRegexp("^select").WillReturnRows([]string{"name"}, []interface{}{"a"})
+	if _, err := QueryGraph[noKey](context.Background(), db, "select name from invoices"); err == nil {
+		t.Fatal("expected the error of the primary key")
+	}
+}
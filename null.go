@@ -0,0 +1,199 @@
+package sql
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// Null is a nullable T, such as sql.NullString for string. It is written as null if Valid is false, and is marshaled to JSON as null.
+type Null[T any] struct {
+	V     T
+	Valid bool
+}
+
+func NewNull[T any](v T) Null[T] {
+	return Null[T]{V: v, Valid: true}
+}
+
+// Ptr returns nil if n is not valid, otherwise returns the pointer to a copy of V.
+func (n Null[T]) Ptr() *T {
+	if !n.Valid {
+		return nil
+	}
+	v := n.V
+	return &v
+}
+func (n Null[T]) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return driver.DefaultParameterConverter.ConvertValue(n.V)
+}
+func (n *Null[T]) Scan(src interface{}) error {
+	if src == nil {
+		var v T
+		n.V, n.Valid = v, false
+		return nil
+	}
+	if scanner, ok := interface{}(&n.V).(sql.Scanner); ok {
+		if err := scanner.Scan(src); err != nil {
+			return err
+		}
+		n.Valid = true
+		return nil
+	}
+	if err := assignValue(reflect.ValueOf(&n.V).Elem(), src); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
+func (n Null[T]) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(n.V)
+}
+func (n *Null[T]) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		var v T
+		n.V, n.Valid = v, false
+		return nil
+	}
+	if err := json.Unmarshal(data, &n.V); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
+
+// assignValue sets the value of the driver into v, such as []byte to string, or int64 to int.
+func assignValue(v reflect.Value, src interface{}) error {
+	sv := reflect.ValueOf(src)
+	if sv.Type().AssignableTo(v.Type()) {
+		v.Set(sv)
+		return nil
+	}
+	var s string
+	switch x := src.(type) {
+	case []byte:
+		s = string(x)
+	case string:
+		s = x
+	case time.Time:
+		s = x.Format(time.RFC3339Nano)
+	default:
+		if sv.Type().ConvertibleTo(v.Type()) && v.Kind() != reflect.String {
+			v.Set(sv.Convert(v.Type()))
+			return nil
+		}
+		s = fmt.Sprint(src)
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("cannot scan %T into %s", src, v.Type())
+		}
+		v.SetBytes([]byte(s))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(i)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("cannot scan %T into %s", src, v.Type())
+	}
+	return nil
+}
+
+// IsNull returns true if v is nil, a nil pointer, or a driver.Valuer whose value is nil, such as an invalid sql.NullString or Null[T].
+func IsNull(v interface{}) bool {
+	_, null := GetNullValue(v)
+	return null
+}
+
+// GetNullValue returns true if v is null, as IsNull. Otherwise, it returns the value to write: the value of a driver.Valuer is kept, so Value is not called again by the driver.
+// If Value returns an error, v is returned, so the error is returned when v is written.
+func GetNullValue(v interface{}) (interface{}, bool) {
+	if v == nil {
+		return nil, true
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return nil, true
+	}
+	if valuer, ok := v.(driver.Valuer); ok {
+		x, err := valuer.Value()
+		if err != nil {
+			return v, false
+		}
+		if x == nil {
+			return nil, true
+		}
+		return dbValue{v: x}, false
+	}
+	return v, false
+}
+
+// GetNullableBool returns the value of a nullable bool, such as *bool, sql.NullBool or Null[bool], and false if it is nil or invalid.
+// A bool is not nullable, so it returns false, such as for the filters with the tag operator:"null", which are skipped if they are not set.
+func GetNullableBool(v interface{}) (bool, bool) {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		return false, false
+	}
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return false, false
+		}
+		if b, ok := v.(*bool); ok {
+			return *b, true
+		}
+		v = rv.Elem().Interface()
+	}
+	valuer, ok := v.(driver.Valuer)
+	if !ok {
+		return false, false
+	}
+	x, err := valuer.Value()
+	if err != nil {
+		return false, false
+	}
+	b, ok := x.(bool)
+	return b, ok
+}
+
+// dbValue is the value of a driver.Valuer, which is computed by GetNullValue.
+type dbValue struct {
+	v driver.Value
+}
+
+func (d dbValue) Value() (driver.Value, error) {
+	return d.v, nil
+}
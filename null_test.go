@@ -0,0 +1,53 @@
+package sql
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestGetNullableBool(t *testing.T) {
+	yes, no := true, false
+	tests := []struct {
+		name  string
+		v     interface{}
+		value bool
+		ok    bool
+	}{
+		{"nil", nil, false, false},
+		{"bool", true, false, false},
+		{"nil *bool", (*bool)(nil), false, false},
+		{"*bool true", &yes, true, true},
+		{"*bool false", &no, false, true},
+		{"sql.NullBool", sql.NullBool{Bool: true, Valid: true}, true, true},
+		{"invalid sql.NullBool", sql.NullBool{Bool: true}, false, false},
+		{"*sql.NullBool", &sql.NullBool{Valid: true}, false, true},
+		{"Null[bool]", NewNull(true), true, true},
+		{"invalid Null[bool]", Null[bool]{}, false, false},
+		{"Null[string]", NewNull("true"), false, false},
+	}
+	for _, tt := range tests {
+		value, ok := GetNullableBool(tt.v)
+		if value != tt.value || ok != tt.ok {
+			t.Errorf("%s: expected %v %v, got %v %v", tt.name, tt.value, tt.ok, value, ok)
+		}
+	}
+}
+
+func TestGetNullValue(t *testing.T) {
+	if _, null := GetNullValue(Null[int]{}); !null {
+		t.Error("expected an invalid Null[int] to be null")
+	}
+	if _, null := GetNullValue((*int)(nil)); !null {
+		t.Error("expected a nil pointer to be null")
+	}
+	v, null := GetNullValue(sql.NullString{String: "a", Valid: true})
+	if null {
+		t.Fatal("expected a valid sql.NullString not to be null")
+	}
+	if x, _ := v.(dbValue); x.v != "a" {
+		t.Errorf("expected the value of the valuer, got %v", v)
+	}
+	if v, null := GetNullValue(5); null || v != 5 {
+		t.Errorf("expected 5, got %v %v", v, null)
+	}
+}
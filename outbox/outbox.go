@@ -0,0 +1,76 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	q "github.com/core-go/sql"
+)
+
+// Message is a row of the outbox table. Id is generated by the database, such as serial or auto_increment, so the messages are relayed in the order of Id.
+type Message struct {
+	Id        int64     `json:"id,omitempty" gorm:"column:id;primary_key"`
+	Topic     string    `json:"topic,omitempty" gorm:"column:topic"`
+	Key       string    `json:"key,omitempty" gorm:"column:msgkey"`
+	Payload   []byte    `json:"payload,omitempty" gorm:"column:payload"`
+	CreatedAt time.Time `json:"createdAt,omitempty" gorm:"column:createdat"`
+	Attempts  int       `json:"attempts,omitempty" gorm:"column:attempts"`
+}
+
+// Columns are the columns of the outbox table. SentAt is null until the message is published, if the messages are not purged.
+type Columns struct {
+	Id        string
+	Topic     string
+	Key       string
+	Payload   string
+	CreatedAt string
+	Attempts  string
+	SentAt    string
+}
+
+var DefaultColumns = Columns{Id: "id", Topic: "topic", Key: "msgkey", Payload: "payload", CreatedAt: "createdat", Attempts: "attempts", SentAt: "sentat"}
+
+// Outbox writes the messages into the outbox table, by the transaction of the context if any, so the messages are committed atomically with the business data.
+type Outbox struct {
+	db         *sql.DB
+	tableName  string
+	Columns    Columns
+	Tx         string
+	BuildParam func(i int) string
+}
+
+func NewOutbox(db *sql.DB, tableName string, options ...string) *Outbox {
+	return NewOutboxWithColumns(db, tableName, DefaultColumns, options...)
+}
+
+// NewOutboxWithColumns creates an Outbox. options[0] is the key of the transaction in the context, "tx" by default, as GetExec.
+func NewOutboxWithColumns(db *sql.DB, tableName string, columns Columns, options ...string) *Outbox {
+	var tx string
+	if len(options) > 0 {
+		tx = options[0]
+	}
+	return &Outbox{db: db, tableName: tableName, Columns: columns, Tx: tx, BuildParam: q.GetBuild(db)}
+}
+
+// Add writes a message into the outbox table. Call it with the context of Begin, to commit the message with the business data.
+func (o *Outbox) Add(ctx context.Context, topic string, key string, payload []byte) error {
+	c := o.Columns
+	query := fmt.Sprintf("insert into %s (%s, %s, %s, %s, %s) values (%s, %s, %s, %s, %s)", o.tableName,
+		c.Topic, c.Key, c.Payload, c.CreatedAt, c.Attempts,
+		o.BuildParam(1), o.BuildParam(2), o.BuildParam(3), o.BuildParam(4), o.BuildParam(5))
+	_, err := q.GetExec(ctx, o.db, o.Tx).ExecContext(ctx, query, topic, key, payload, time.Now(), 0)
+	return err
+}
+
+func buildIn(buildParam func(int) string, ids []int64, i int) (string, []interface{}) {
+	params := make([]string, len(ids))
+	args := make([]interface{}, len(ids))
+	for j, id := range ids {
+		params[j] = buildParam(i + j)
+		args[j] = id
+	}
+	return strings.Join(params, ", "), args
+}
@@ -0,0 +1,159 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	q "github.com/core-go/sql"
+)
+
+// Relay polls the unsent messages of the outbox table in the order of Id, publishes them, then marks them as sent or deletes them.
+// The messages are locked by "for update skip locked" where it is supported, so many relays can run together.
+// The delivery is at least once: if the transaction fails after Publish, the messages are published again.
+type Relay struct {
+	db         *sql.DB
+	tableName  string
+	Columns    Columns
+	Driver     string
+	BuildParam func(i int) string
+	Publish    func(ctx context.Context, msg Message) error
+	BatchSize  int
+	Interval   time.Duration
+	// MaxAttempts is the maximum number of the failed attempts of a message. Then the message is not relayed anymore, to be handled manually. It is unlimited if 0
+	MaxAttempts int
+	// Purge deletes the published messages, instead of setting the column SentAt
+	Purge   bool
+	OnError func(err error)
+}
+
+// NewRelay creates a Relay. If options[0] is true, the published messages are deleted. If interval is not positive, it is 1 second.
+func NewRelay(db *sql.DB, tableName string, publish func(context.Context, Message) error, batchSize int, interval time.Duration, options ...bool) *Relay {
+	return NewRelayWithColumns(db, tableName, DefaultColumns, publish, batchSize, interval, options...)
+}
+func NewRelayWithColumns(db *sql.DB, tableName string, columns Columns, publish func(context.Context, Message) error, batchSize int, interval time.Duration, options ...bool) *Relay {
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	if interval <= 0 {
+		interval = time.Second
+	}
+	purge := len(options) > 0 && options[0]
+	return &Relay{db: db, tableName: tableName, Columns: columns, Driver: q.GetDriver(db), BuildParam: q.GetBuild(db), Publish: publish, BatchSize: batchSize, Interval: interval, Purge: purge}
+}
+
+// Run relays the messages until ctx is done. It waits for Interval when there is no more message, or when Relay fails.
+func (r *Relay) Run(ctx context.Context) error {
+	interval := r.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	for {
+		n, err := r.Relay(ctx)
+		if err != nil && r.OnError != nil && ctx.Err() == nil {
+			r.OnError(err)
+		}
+		if err != nil || n < r.BatchSize {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(interval):
+			}
+		} else if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+// Relay publishes a batch of the unsent messages, in a transaction. It stops at the first message which fails, to keep the order, and increases its attempts.
+// It returns the number of the published messages, and the error of Publish if any.
+func (r *Relay) Relay(ctx context.Context) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	msgs, err := r.poll(ctx, tx)
+	if err != nil {
+		return 0, err
+	}
+	ids := make([]int64, 0, len(msgs))
+	var er1 error
+	for _, msg := range msgs {
+		if er1 = r.Publish(ctx, msg); er1 != nil {
+			c := r.Columns
+			query := fmt.Sprintf("update %s set %s = %s + 1 where %s = %s", r.tableName, c.Attempts, c.Attempts, c.Id, r.BuildParam(1))
+			if _, er2 := tx.ExecContext(ctx, query, msg.Id); er2 != nil {
+				return 0, er2
+			}
+			break
+		}
+		ids = append(ids, msg.Id)
+	}
+	if len(ids) > 0 {
+		if er2 := r.done(ctx, tx, ids); er2 != nil {
+			return 0, er2
+		}
+	}
+	if er3 := tx.Commit(); er3 != nil {
+		return 0, er3
+	}
+	return len(ids), er1
+}
+
+func (r *Relay) poll(ctx context.Context, tx *sql.Tx) ([]Message, error) {
+	c := r.Columns
+	columns := fmt.Sprintf("%s, %s, %s, %s, %s, %s", c.Id, c.Topic, c.Key, c.Payload, c.CreatedAt, c.Attempts)
+	where := fmt.Sprintf("%s is null", c.SentAt)
+	if r.Purge {
+		where = "1 = 1"
+	}
+	var args []interface{}
+	if r.MaxAttempts > 0 {
+		where = fmt.Sprintf("%s and %s < %s", where, c.Attempts, r.BuildParam(1))
+		args = append(args, r.MaxAttempts)
+	}
+	var query string
+	switch r.Driver {
+	case q.DriverPostgres, q.DriverMysql:
+		query = fmt.Sprintf("select %s from %s where %s order by %s limit %d for update skip locked", columns, r.tableName, where, c.Id, r.BatchSize)
+	case q.DriverOracle:
+		// the rows are locked when they are fetched, so only BatchSize rows are locked
+		query = fmt.Sprintf("select %s from %s where %s order by %s for update skip locked", columns, r.tableName, where, c.Id)
+	case q.DriverMssql:
+		query = fmt.Sprintf("select top %d %s from %s with (updlock, readpast, rowlock) where %s order by %s", r.BatchSize, columns, r.tableName, where, c.Id)
+	default:
+		query = fmt.Sprintf("select %s from %s where %s order by %s limit %d", columns, r.tableName, where, c.Id, r.BatchSize)
+	}
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	msgs := make([]Message, 0)
+	for len(msgs) < r.BatchSize && rows.Next() {
+		var msg Message
+		if err = rows.Scan(&msg.Id, &msg.Topic, &msg.Key, &msg.Payload, &msg.CreatedAt, &msg.Attempts); err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, rows.Err()
+}
+func (r *Relay) done(ctx context.Context, tx *sql.Tx, ids []int64) error {
+	c := r.Columns
+	var query string
+	var args []interface{}
+	if r.Purge {
+		in, params := buildIn(r.BuildParam, ids, 1)
+		query = fmt.Sprintf("delete from %s where %s in (%s)", r.tableName, c.Id, in)
+		args = params
+	} else {
+		in, params := buildIn(r.BuildParam, ids, 2)
+		query = fmt.Sprintf("update %s set %s = %s where %s in (%s)", r.tableName, c.SentAt, r.BuildParam(1), c.Id, in)
+		args = append([]interface{}{time.Now()}, params...)
+	}
+	_, err := tx.ExecContext(ctx, query, args...)
+	return err
+}
@@ -0,0 +1,131 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/core-go/sql/mock"
+)
+
+var messageColumns = []string{"id", "topic", "msgkey", "payload", "createdat", "attempts"}
+
+func TestRelayPollByDialect(t *testing.T) {
+	tests := []struct {
+		dialect string
+		query   string
+	}{
+		{"postgres", "select id, topic, msgkey, payload, createdat, attempts from outbox where sentat is null order by id limit 10 for update skip locked"},
+		{"mysql", "select id, topic, msgkey, payload, createdat, attempts from outbox where sentat is null order by id limit 10 for update skip locked"},
+		{"oracle", "select id, topic, msgkey, payload, createdat, attempts from outbox where sentat is null order by id for update skip locked"},
+		{"mssql", "select top 10 id, topic, msgkey, payload, createdat, attempts from outbox with (updlock, readpast, rowlock) where sentat is null order by id"},
+		{"sqlite3", "select id, topic, msgkey, payload, createdat, attempts from outbox where sentat is null order by id limit 10"},
+	}
+	for _, tt := range tests {
+		db, rec := mock.New(tt.dialect)
+		r := NewRelay(db, "outbox", func(context.Context, Message) error { return nil }, 10, time.Second)
+		if _, err := r.Relay(context.Background()); err != nil {
+			t.Fatalf("%s: %v", tt.dialect, err)
+		}
+		rec.AssertStatement(t, tt.query)
+	}
+}
+
+func TestRelayMarksPublishedMessages(t *testing.T) {
+	db, rec := mock.New("postgres")
+	now := time.Now()
+	rec.ExpectRegexp("^select").WillReturnRows(messageColumns,
+		[]interface{}{int64(1), "t", "k1", []byte("a"), now, int64(0)},
+		[]interface{}{int64(2), "t", "k2", []byte("b"), now, int64(0)})
+	var published []int64
+	r := NewRelay(db, "outbox", func(ctx context.Context, msg Message) error {
+		published = append(published, msg.Id)
+		return nil
+	}, 10, time.Second)
+	n, err := r.Relay(context.Background())
+	if err != nil || n != 2 {
+		t.Fatalf("expected 2 messages, got %d %v", n, err)
+	}
+	if len(published) != 2 || published[0] != 1 || published[1] != 2 {
+		t.Fatalf("expected the messages in the order of id, got %v", published)
+	}
+	rec.AssertStatement(t, "update outbox set sentat = $1 where id in ($2, $3)", mock.AnyArg, int64(1), int64(2))
+	assertLast(t, rec, mock.Commit)
+}
+
+func TestRelayStopsAtFailedMessage(t *testing.T) {
+	db, rec := mock.New("mysql")
+	now := time.Now()
+	rec.ExpectRegexp("^select").WillReturnRows(messageColumns,
+		[]interface{}{int64(1), "t", "k1", []byte("a"), now, int64(0)},
+		[]interface{}{int64(2), "t", "k2", []byte("b"), now, int64(0)},
+		[]interface{}{int64(3), "t", "k3", []byte("c"), now, int64(0)})
+	failed := errors.New("publish failed")
+	r := NewRelay(db, "outbox", func(ctx context.Context, msg Message) error {
+		if msg.Id == 2 {
+			return failed
+		}
+		return nil
+	}, 10, time.Second, true)
+	n, err := r.Relay(context.Background())
+	if err != failed || n != 1 {
+		t.Fatalf("expected 1 message and the error of Publish, got %d %v", n, err)
+	}
+	rec.AssertStatement(t, "update outbox set attempts = attempts + 1 where id = ?", int64(2))
+	rec.AssertStatement(t, "delete from outbox where id in (?)", int64(1))
+	assertLast(t, rec, mock.Commit)
+}
+
+func TestRelayMaxAttempts(t *testing.T) {
+	db, rec := mock.New("postgres")
+	r := NewRelay(db, "outbox", func(context.Context, Message) error { return nil }, 10, time.Second)
+	r.MaxAttempts = 3
+	r.Relay(context.Background())
+	rec.AssertStatement(t, "select id, topic, msgkey, payload, createdat, attempts from outbox where sentat is null and attempts < $1 order by id limit 10 for update skip locked", 3)
+}
+
+func TestNewRelayDefaultInterval(t *testing.T) {
+	db, _ := mock.New("postgres")
+	r := NewRelay(db, "outbox", func(context.Context, Message) error { return nil }, 10, 0)
+	if r.Interval <= 0 {
+		t.Fatalf("expected a positive interval, got %v", r.Interval)
+	}
+}
+
+func TestRelayRunWaitsWhenEmpty(t *testing.T) {
+	db, rec := mock.New("postgres")
+	r := NewRelay(db, "outbox", func(context.Context, Message) error { return nil }, 10, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := r.Run(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected DeadlineExceeded, got %v", err)
+	}
+	polls := 0
+	for _, s := range rec.Statements() {
+		if strings.HasPrefix(s.Query, "select") {
+			polls++
+		}
+	}
+	if polls != 1 {
+		t.Fatalf("expected 1 poll of the empty outbox, got %d", polls)
+	}
+}
+
+func TestOutboxAdd(t *testing.T) {
+	db, rec := mock.New("postgres")
+	o := NewOutbox(db, "outbox")
+	if err := o.Add(context.Background(), "t", "k", []byte("p")); err != nil {
+		t.Fatal(err)
+	}
+	rec.AssertStatement(t, "insert into outbox (topic, msgkey, payload, createdat, attempts) values ($1, $2, $3, $4, $5)", "t", "k", []byte("p"), mock.AnyArg, 0)
+}
+
+func assertLast(t *testing.T, rec *mock.Recorder, query string) {
+	t.Helper()
+	statements := rec.Statements()
+	if len(statements) == 0 || statements[len(statements)-1].Query != query {
+		t.Errorf("expected %s at last, got %v", query, statements)
+	}
+}
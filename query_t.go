@@ -0,0 +1,119 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+)
+
+var ErrNotFound = errors.New("not found")
+
+// UseTx returns the transaction of the context if db is *sql.DB, otherwise returns db.
+func UseTx(ctx context.Context, db Executor, txKey ...string) Executor {
+	if x, ok := db.(*sql.DB); ok {
+		return GetExec(ctx, x, txKey...)
+	}
+	return db
+}
+
+func QueryT[T any](ctx context.Context, db Executor, query string, args ...interface{}) ([]T, error) {
+	return QueryTWithArray[T](ctx, db, nil, query, args...)
+}
+func QueryTWithArray[T any](ctx context.Context, db Executor, toArray func(interface{}) interface {
+	driver.Valuer
+	sql.Scanner
+}, query string, args ...interface{}) ([]T, error) {
+	objs := make([]T, 0)
+	err := EachWithArray[T](ctx, UseTx(ctx, db), nil, toArray, true, query, args, func(obj *T) error {
+		objs = append(objs, *obj)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return objs, nil
+}
+
+// QueryOneT returns the first row, or ErrNotFound if there is no row.
+func QueryOneT[T any](ctx context.Context, db Executor, query string, args ...interface{}) (*T, error) {
+	return QueryOneTWithArray[T](ctx, db, nil, query, args...)
+}
+func QueryOneTWithArray[T any](ctx context.Context, db Executor, toArray func(interface{}) interface {
+	driver.Valuer
+	sql.Scanner
+}, query string, args ...interface{}) (*T, error) {
+	var obj *T
+	err := EachWithArray[T](ctx, UseTx(ctx, db), nil, toArray, false, query, args, func(t *T) error {
+		obj = t
+		return ErrStop
+	})
+	if err != nil {
+		return nil, err
+	}
+	if obj == nil {
+		return nil, ErrNotFound
+	}
+	return obj, nil
+}
+
+// QueryScalar returns the first column of the first row, or ErrNotFound if there is no row.
+func QueryScalar[V any](ctx context.Context, db Executor, query string, args ...interface{}) (V, error) {
+	var v V
+	query, args = ExpandIn(query, ConvertArgs(args)...)
+	err := UseTx(ctx, db).QueryRowContext(ctx, query, args...).Scan(&v)
+	if err == sql.ErrNoRows {
+		return v, ErrNotFound
+	}
+	return v, err
+}
+
+// QueryMapT scans all columns into V. If V is interface{}, []byte values are converted to string, the same as QueryMap.
+func QueryMapT[V any](ctx context.Context, db Executor, transform func(s string) string, query string, args ...interface{}) ([]map[string]V, error) {
+	query, args = ExpandIn(query, ConvertArgs(args)...)
+	rows, er1 := UseTx(ctx, db).QueryContext(ctx, query, args...)
+	if er1 != nil {
+		return nil, er1
+	}
+	defer rows.Close()
+	cols, er2 := rows.Columns()
+	if er2 != nil {
+		return nil, er2
+	}
+	names := make([]string, len(cols))
+	for i, col := range cols {
+		if transform != nil {
+			names[i] = transform(col)
+		} else {
+			names[i] = col
+		}
+	}
+	res := make([]map[string]V, 0)
+	values := make([]V, len(cols))
+	pointers := make([]interface{}, len(cols))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+		m := make(map[string]V, len(cols))
+		for i, v := range values {
+			if b, ok := interface{}(v).([]byte); ok {
+				if s, ok := interface{}(string(b)).(V); ok {
+					v = s
+				}
+			}
+			m[names[i]] = v
+		}
+		res = append(res, m)
+	}
+	if er3 := rows.Err(); er3 != nil {
+		return nil, er3
+	}
+	return res, rows.Close()
+}
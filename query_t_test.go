@@ -0,0 +1,79 @@
+package sql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/core-go/sql/mock"
+)
+
+func TestQueryT(t *testing.T) {
+	db, rec := mock.New("postgres")
+	rec.ExpectRegexp("^select").WillReturnRows([]string{"id", "name"}, []interface{}{"1", "a"}, []interface{}{"2", "b"})
+	items, err := QueryT[testItem](context.Background(), db, "select id, name from items where id in (?)", []string{"1", "2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.AssertStatement(t, "select id, name from items where id in (?,?)", "1", "2")
+	if len(items) != 2 || items[0].Id != "1" || items[1].Name != "b" {
+		t.Fatalf("expected 2 items, got %v", items)
+	}
+	items, err = QueryT[testItem](context.Background(), db, "select id, name from items")
+	if err != nil || items == nil || len(items) != 0 {
+		t.Fatalf("expected an empty slice, got %v %v", items, err)
+	}
+}
+
+func TestQueryOneT(t *testing.T) {
+	db, rec := mock.New("mysql")
+	rec.ExpectRegexp("^select").WillReturnRows([]string{"id", "name"}, []interface{}{"1", "a"}, []interface{}{"2", "b"})
+	item, err := QueryOneT[testItem](context.Background(), db, "select id, name from items")
+	if err != nil || item.Id != "1" {
+		t.Fatalf("expected the first item, got %v %v", item, err)
+	}
+	if _, err := QueryOneT[testItem](context.Background(), db, "select id, name from items"); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestQueryScalar(t *testing.T) {
+	db, rec := mock.New("postgres")
+	rec.ExpectRegexp("^select count").WillReturnRows([]string{"count"}, []interface{}{int64(3)})
+	n, err := QueryScalar[int64](context.Background(), db, "select count(*) from items where id in (?)", []int{1, 2})
+	if err != nil || n != 3 {
+		t.Fatalf("expected 3, got %v %v", n, err)
+	}
+	rec.AssertStatement(t, "select count(*) from items where id in (?,?)", 1, 2)
+	if _, err := QueryScalar[int64](context.Background(), db, "select count(*) from items"); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestQueryMapT(t *testing.T) {
+	db, rec := mock.New("postgres")
+	rec.ExpectRegexp("^select").WillReturnRows([]string{"id", "name"}, []interface{}{int64(1), []byte("a")})
+	rows, err := QueryMapT[interface{}](context.Background(), db, nil, "select id, name from items")
+	if err != nil || len(rows) != 1 {
+		t.Fatalf("expected 1 row, got %v %v", rows, err)
+	}
+	if rows[0]["id"] != int64(1) || rows[0]["name"] != "a" {
+		t.Fatalf("expected the []byte to be converted to string, got %v", rows[0])
+	}
+}
+
+func TestQueryTUsesTx(t *testing.T) {
+	db, rec := mock.New("postgres")
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	ctx := context.WithValue(context.Background(), "tx", tx)
+	if _, err := QueryT[testItem](ctx, db, "select id, name from items"); err != nil {
+		t.Fatal(err)
+	}
+	statements := rec.Statements()
+	if len(statements) != 2 || statements[1].Query != "select id, name from items" || !statements[1].Tx {
+		t.Fatalf("expected the query to be run in the transaction of the context, got %v", statements)
+	}
+}
@@ -0,0 +1,75 @@
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	q "github.com/core-go/sql"
+)
+
+const (
+	StatusQueued = "queued"
+	StatusDead   = "dead"
+)
+
+// Job is a row of the job table, such as:
+// create table jobs (id bigserial primary key, queue varchar(100), payload bytea, priority int, runat timestamp, attempts int, lockeduntil timestamp, lasterror varchar(1000), status varchar(10))
+// The jobs are deleted when they succeed, and their status is "dead" when they fail MaxAttempts times.
+type Job struct {
+	Id        int64     `json:"id,omitempty" gorm:"column:id;primary_key"`
+	Queue     string    `json:"queue,omitempty" gorm:"column:queue"`
+	Payload   []byte    `json:"payload,omitempty" gorm:"column:payload"`
+	Priority  int       `json:"priority,omitempty" gorm:"column:priority"`
+	RunAt     time.Time `json:"runAt,omitempty" gorm:"column:runat"`
+	Attempts  int       `json:"attempts,omitempty" gorm:"column:attempts"`
+	LastError string    `json:"lastError,omitempty" gorm:"column:lasterror"`
+}
+
+// Queue enqueues the jobs, by the transaction of the context if any, so the jobs are committed atomically with the business data.
+type Queue struct {
+	db         *sql.DB
+	tableName  string
+	Tx         string
+	BuildParam func(i int) string
+}
+
+// NewQueue creates a Queue. options[0] is the job table, "jobs" by default, options[1] is the key of the transaction in the context, as GetExec.
+func NewQueue(db *sql.DB, options ...string) *Queue {
+	tableName := "jobs"
+	if len(options) > 0 && len(options[0]) > 0 {
+		tableName = options[0]
+	}
+	var tx string
+	if len(options) > 1 {
+		tx = options[1]
+	}
+	return &Queue{db: db, tableName: tableName, Tx: tx, BuildParam: q.GetBuild(db)}
+}
+
+// Enqueue adds a job to the queue, which runs at runAt or later. options[0] is the priority, the jobs of higher priority run first.
+func (s *Queue) Enqueue(ctx context.Context, queue string, payload []byte, runAt time.Time, options ...int) error {
+	var priority int
+	if len(options) > 0 {
+		priority = options[0]
+	}
+	if runAt.IsZero() {
+		runAt = time.Now()
+	}
+	query := fmt.Sprintf("insert into %s (queue, payload, priority, runat, attempts, status) values (%s, %s, %s, %s, %s, %s)", s.tableName,
+		s.BuildParam(1), s.BuildParam(2), s.BuildParam(3), s.BuildParam(4), s.BuildParam(5), s.BuildParam(6))
+	_, err := q.GetExec(ctx, s.db, s.Tx).ExecContext(ctx, query, queue, payload, priority, runAt, 0, StatusQueued)
+	return err
+}
+
+// Retry requeues a dead job, with its attempts reset.
+func (s *Queue) Retry(ctx context.Context, id int64) (int64, error) {
+	query := fmt.Sprintf("update %s set status = %s, attempts = 0, runat = %s, lockeduntil = null where id = %s and status = %s", s.tableName,
+		s.BuildParam(1), s.BuildParam(2), s.BuildParam(3), s.BuildParam(4))
+	res, err := q.GetExec(ctx, s.db, s.Tx).ExecContext(ctx, query, StatusQueued, time.Now(), id, StatusDead)
+	if err != nil {
+		return -1, err
+	}
+	return res.RowsAffected()
+}
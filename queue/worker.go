@@ -0,0 +1,224 @@
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	q "github.com/core-go/sql"
+)
+
+// ErrClaimLost is returned by RunOne if the job is claimed by another worker, after Visibility, before the job is done.
+var ErrClaimLost = errors.New("job is claimed by another worker")
+
+// Worker runs the jobs of a queue by a pool of goroutines.
+// A job is claimed by "for update skip locked" for Postgres, My SQL and Oracle, by "readpast" for MS SQL, and by a conditional update for SQLite,
+// then it is invisible to the other workers for Visibility. If the worker stops before the job is done, the job runs again after Visibility.
+type Worker struct {
+	db          *sql.DB
+	tableName   string
+	Queue       string
+	Driver      string
+	BuildParam  func(i int) string
+	Handle      func(ctx context.Context, job Job) error
+	Concurrency int
+	// Visibility is the time to run a job, before it is claimed by another worker. The context of Handle is canceled after Visibility
+	Visibility time.Duration
+	// MaxAttempts is the number of attempts before the job is dead
+	MaxAttempts int
+	// Backoff is the delay before the next attempt, by the number of attempts. It is exponential from 1 second to 1 hour by default
+	Backoff  func(attempts int) time.Duration
+	Interval time.Duration
+	OnError  func(err error)
+}
+
+// NewWorker creates a Worker of the queue. options[0] is the job table, "jobs" by default.
+func NewWorker(db *sql.DB, queue string, handle func(context.Context, Job) error, concurrency int, options ...string) *Worker {
+	tableName := "jobs"
+	if len(options) > 0 && len(options[0]) > 0 {
+		tableName = options[0]
+	}
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	return &Worker{db: db, tableName: tableName, Queue: queue, Driver: q.GetDriver(db), BuildParam: q.GetBuild(db), Handle: handle, Concurrency: concurrency,
+		Visibility: 5 * time.Minute, MaxAttempts: 5, Backoff: ExponentialBackoff(time.Second, time.Hour), Interval: time.Second}
+}
+
+// ExponentialBackoff returns base * 2^(attempts-1), which is max at most.
+func ExponentialBackoff(base time.Duration, max time.Duration) func(int) time.Duration {
+	return func(attempts int) time.Duration {
+		d := base
+		for i := 1; i < attempts && d < max; i++ {
+			d = d * 2
+		}
+		if d > max {
+			return max
+		}
+		return d
+	}
+}
+
+// Run runs the jobs until ctx is done. Then it stops claiming the jobs, and waits for the running jobs to finish.
+func (w *Worker) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for i := 0; i < w.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				ok, err := w.RunOne(ctx)
+				if err != nil && w.OnError != nil && ctx.Err() == nil {
+					w.OnError(err)
+				}
+				if !ok || err != nil {
+					select {
+					case <-ctx.Done():
+					case <-time.After(w.Interval):
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+// RunOne claims a job and runs it. It returns false if there is no job ready. The error of Handle is not returned, but is saved in the column lasterror.
+// The job is deleted or updated only if its attempts are not changed, otherwise it returns ErrClaimLost, because the job is claimed again by another worker.
+func (w *Worker) RunOne(ctx context.Context) (bool, error) {
+	job, ok, err := w.claim(ctx)
+	if err != nil || !ok {
+		return false, err
+	}
+	// the job runs to the end even if ctx is done, for graceful shutdown
+	c, cancel := context.WithTimeout(context.Background(), w.Visibility)
+	er1 := w.Handle(c, job)
+	cancel()
+	c2, cancel2 := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel2()
+	if er1 == nil {
+		query := fmt.Sprintf("delete from %s where id = %s and attempts = %s", w.tableName, w.BuildParam(1), w.BuildParam(2))
+		return true, checkClaim(w.db.ExecContext(c2, query, job.Id, job.Attempts))
+	}
+	msg := er1.Error()
+	if len(msg) > 1000 {
+		msg = msg[:1000]
+	}
+	if w.MaxAttempts > 0 && job.Attempts >= w.MaxAttempts {
+		query := fmt.Sprintf("update %s set status = %s, lasterror = %s, lockeduntil = null where id = %s and attempts = %s", w.tableName, w.BuildParam(1), w.BuildParam(2), w.BuildParam(3), w.BuildParam(4))
+		return true, checkClaim(w.db.ExecContext(c2, query, StatusDead, msg, job.Id, job.Attempts))
+	}
+	runAt := time.Now()
+	if w.Backoff != nil {
+		runAt = runAt.Add(w.Backoff(job.Attempts))
+	}
+	query := fmt.Sprintf("update %s set runat = %s, lasterror = %s, lockeduntil = null where id = %s and attempts = %s", w.tableName, w.BuildParam(1), w.BuildParam(2), w.BuildParam(3), w.BuildParam(4))
+	return true, checkClaim(w.db.ExecContext(c2, query, runAt, msg, job.Id, job.Attempts))
+}
+
+// checkClaim returns ErrClaimLost if no row is affected.
+func checkClaim(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	if n, er1 := res.RowsAffected(); er1 == nil && n == 0 {
+		return ErrClaimLost
+	}
+	return nil
+}
+
+const jobColumns = "id, queue, payload, priority, runat, attempts"
+
+// claim selects the next job by priority and runat, then sets lockeduntil and increases attempts.
+func (w *Worker) claim(ctx context.Context) (Job, bool, error) {
+	var job Job
+	now := time.Now()
+	where := fmt.Sprintf("queue = %s and status = %s and runat <= %s and (lockeduntil is null or lockeduntil < %s)", w.BuildParam(1), w.BuildParam(2), w.BuildParam(3), w.BuildParam(4))
+	args := []interface{}{w.Queue, StatusQueued, now, now}
+	order := "priority desc, runat, id"
+	var query string
+	switch w.Driver {
+	case q.DriverPostgres, q.DriverMysql:
+		query = fmt.Sprintf("select %s from %s where %s order by %s limit 1 for update skip locked", jobColumns, w.tableName, where, order)
+	case q.DriverOracle:
+		// "fetch first" is not allowed with "for update", so the first job is selected by a subquery, then only this job is locked
+		query = fmt.Sprintf("select %s from %s where id = (select id from %s where %s order by %s fetch first 1 rows only) for update skip locked", jobColumns, w.tableName, w.tableName, where, order)
+	case q.DriverMssql:
+		query = fmt.Sprintf("select top 1 %s from %s with (updlock, readpast, rowlock) where %s order by %s", jobColumns, w.tableName, where, order)
+	default:
+		return w.claimOptimistic(ctx, where, args, order)
+	}
+	tx, err := w.db.BeginTx(ctx, nil)
+	if err != nil {
+		return job, false, err
+	}
+	defer tx.Rollback()
+	ok, err := scanJob(ctx, tx, &job, query, args...)
+	if err != nil || !ok {
+		return job, false, err
+	}
+	if err = w.lock(ctx, tx, &job, now, false); err != nil {
+		return job, false, err
+	}
+	if err = tx.Commit(); err != nil {
+		return job, false, err
+	}
+	return job, true, nil
+}
+
+// claimOptimistic is for the databases without "skip locked", such as SQLite: the job is claimed if it is not claimed by another worker after it is selected.
+func (w *Worker) claimOptimistic(ctx context.Context, where string, args []interface{}, order string) (Job, bool, error) {
+	var job Job
+	query := fmt.Sprintf("select %s from %s where %s order by %s limit 1", jobColumns, w.tableName, where, order)
+	for i := 0; i < 3; i++ {
+		ok, err := scanJob(ctx, w.db, &job, query, args...)
+		if err != nil || !ok {
+			return job, false, err
+		}
+		err = w.lock(ctx, w.db, &job, args[3].(time.Time), true)
+		if err == nil {
+			return job, true, nil
+		}
+		if err != sql.ErrNoRows {
+			return job, false, err
+		}
+	}
+	return job, false, nil
+}
+
+// lock sets lockeduntil and increases attempts. If optimistic is true, it returns sql.ErrNoRows if the job is claimed by another worker.
+func (w *Worker) lock(ctx context.Context, db q.Executor, job *Job, now time.Time, optimistic bool) error {
+	query := fmt.Sprintf("update %s set lockeduntil = %s, attempts = attempts + 1 where id = %s", w.tableName, w.BuildParam(1), w.BuildParam(2))
+	args := []interface{}{now.Add(w.Visibility), job.Id}
+	if optimistic {
+		query = query + fmt.Sprintf(" and (lockeduntil is null or lockeduntil < %s)", w.BuildParam(3))
+		args = append(args, now)
+	}
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+	job.Attempts++
+	return nil
+}
+func scanJob(ctx context.Context, db q.Executor, job *Job, query string, args ...interface{}) (bool, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return false, rows.Err()
+	}
+	if err = rows.Scan(&job.Id, &job.Queue, &job.Payload, &job.Priority, &job.RunAt, &job.Attempts); err != nil {
+		return false, err
+	}
+	return true, nil
+}
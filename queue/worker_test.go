@@ -0,0 +1,149 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/core-go/sql/mock"
+)
+
+var jobRow = []string{"id", "queue", "payload", "priority", "runat", "attempts"}
+
+func newJob(id int64, attempts int64) []interface{} {
+	return []interface{}{id, "mail", []byte("p"), int64(0), time.Now(), attempts}
+}
+func noop(context.Context, Job) error {
+	return nil
+}
+
+func TestClaimByDialect(t *testing.T) {
+	where := "queue = %s and status = %s and runat <= %s and (lockeduntil is null or lockeduntil < %s)"
+	tests := []struct {
+		dialect string
+		query   string
+	}{
+		{"postgres", "select id, queue, payload, priority, runat, attempts from jobs where queue = $1 and status = $2 and runat <= $3 and (lockeduntil is null or lockeduntil < $4) order by priority desc, runat, id limit 1 for update skip locked"},
+		{"mysql", "select id, queue, payload, priority, runat, attempts from jobs where " + strings.ReplaceAll(where, "%s", "?") + " order by priority desc, runat, id limit 1 for update skip locked"},
+		{"oracle", "select id, queue, payload, priority, runat, attempts from jobs where id = (select id from jobs where queue = :1 and status = :2 and runat <= :3 and (lockeduntil is null or lockeduntil < :4) order by priority desc, runat, id fetch first 1 rows only) for update skip locked"},
+		{"mssql", "select top 1 id, queue, payload, priority, runat, attempts from jobs with (updlock, readpast, rowlock) where queue = @p1 and status = @p2 and runat <= @p3 and (lockeduntil is null or lockeduntil < @p4) order by priority desc, runat, id"},
+		{"sqlite3", "select id, queue, payload, priority, runat, attempts from jobs where " + strings.ReplaceAll(where, "%s", "?") + " order by priority desc, runat, id limit 1"},
+	}
+	for _, tt := range tests {
+		db, rec := mock.New(tt.dialect)
+		w := NewWorker(db, "mail", noop, 1)
+		ok, err := w.RunOne(context.Background())
+		if ok || err != nil {
+			t.Fatalf("%s: expected no job, got %v %v", tt.dialect, ok, err)
+		}
+		rec.AssertStatement(t, tt.query, "mail", StatusQueued, mock.AnyArg, mock.AnyArg)
+	}
+}
+
+func TestRunOneDeletesDoneJob(t *testing.T) {
+	db, rec := mock.New("postgres")
+	rec.ExpectRegexp("^select").WillReturnRows(jobRow, newJob(7, 2))
+	var handled Job
+	w := NewWorker(db, "mail", func(ctx context.Context, job Job) error {
+		handled = job
+		return nil
+	}, 1)
+	ok, err := w.RunOne(context.Background())
+	if !ok || err != nil {
+		t.Fatalf("expected a job, got %v %v", ok, err)
+	}
+	if handled.Id != 7 || handled.Attempts != 3 {
+		t.Fatalf("expected job 7 at attempt 3, got %v", handled)
+	}
+	rec.AssertStatement(t, "update jobs set lockeduntil = $1, attempts = attempts + 1 where id = $2", mock.AnyArg, int64(7))
+	rec.AssertStatement(t, "delete from jobs where id = $1 and attempts = $2", int64(7), 3)
+}
+
+func TestRunOneReschedulesFailedJob(t *testing.T) {
+	db, rec := mock.New("postgres")
+	rec.ExpectRegexp("^select").WillReturnRows(jobRow, newJob(7, 0))
+	w := NewWorker(db, "mail", func(ctx context.Context, job Job) error {
+		return errors.New("smtp is down")
+	}, 1)
+	start := time.Now()
+	if _, err := w.RunOne(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	rec.AssertStatement(t, "update jobs set runat = $1, lasterror = $2, lockeduntil = null where id = $3 and attempts = $4", mock.AnyArg, "smtp is down", int64(7), 1)
+	for _, s := range rec.Statements() {
+		if strings.HasPrefix(s.Query, "update jobs set runat") {
+			if runAt := s.Args[0].(time.Time); runAt.Before(start.Add(time.Second)) {
+				t.Errorf("expected the backoff of 1 second, got %v", runAt.Sub(start))
+			}
+		}
+	}
+}
+
+func TestRunOneBuriesDeadJob(t *testing.T) {
+	db, rec := mock.New("mysql")
+	rec.ExpectRegexp("^select").WillReturnRows(jobRow, newJob(7, 4))
+	w := NewWorker(db, "mail", func(ctx context.Context, job Job) error {
+		return errors.New("bad payload")
+	}, 1)
+	if _, err := w.RunOne(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	rec.AssertStatement(t, "update jobs set status = ?, lasterror = ?, lockeduntil = null where id = ? and attempts = ?", StatusDead, "bad payload", int64(7), 5)
+}
+
+func TestRunOneLostClaim(t *testing.T) {
+	db, rec := mock.New("postgres")
+	rec.ExpectRegexp("^select").WillReturnRows(jobRow, newJob(7, 0))
+	rec.ExpectRegexp("^delete").WillReturnResult(0, 0)
+	w := NewWorker(db, "mail", noop, 1)
+	ok, err := w.RunOne(context.Background())
+	if !ok || err != ErrClaimLost {
+		t.Fatalf("expected ErrClaimLost, got %v %v", ok, err)
+	}
+}
+
+func TestClaimOptimistic(t *testing.T) {
+	db, rec := mock.New("sqlite3")
+	rec.ExpectRegexp("^select").WillReturnRows(jobRow, newJob(7, 0))
+	rec.ExpectRegexp("^update jobs set lockeduntil").WillReturnResult(0, 0)
+	rec.ExpectRegexp("^select").WillReturnRows(jobRow, newJob(8, 0))
+	var handled int64
+	w := NewWorker(db, "mail", func(ctx context.Context, job Job) error {
+		handled = job.Id
+		return nil
+	}, 1)
+	ok, err := w.RunOne(context.Background())
+	if !ok || err != nil || handled != 8 {
+		t.Fatalf("expected job 8 after job 7 is claimed by another worker, got %v %v %d", ok, err, handled)
+	}
+	rec.AssertStatement(t, "update jobs set lockeduntil = ?, attempts = attempts + 1 where id = ? and (lockeduntil is null or lockeduntil < ?)", mock.AnyArg, int64(8), mock.AnyArg)
+	for _, s := range rec.Statements() {
+		if s.Query == mock.Begin {
+			t.Fatal("expected no transaction for the optimistic claim")
+		}
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	backoff := ExponentialBackoff(time.Second, 10*time.Second)
+	for attempts, expected := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 4: 8 * time.Second, 5: 10 * time.Second, 30: 10 * time.Second} {
+		if d := backoff(attempts); d != expected {
+			t.Errorf("attempts %d: expected %v, got %v", attempts, expected, d)
+		}
+	}
+}
+
+func TestEnqueueAndRetry(t *testing.T) {
+	db, rec := mock.New("postgres")
+	s := NewQueue(db)
+	if err := s.Enqueue(context.Background(), "mail", []byte("p"), time.Time{}, 5); err != nil {
+		t.Fatal(err)
+	}
+	rec.AssertStatement(t, "insert into jobs (queue, payload, priority, runat, attempts, status) values ($1, $2, $3, $4, $5, $6)", "mail", []byte("p"), 5, mock.AnyArg, 0, StatusQueued)
+	if _, err := s.Retry(context.Background(), 7); err != nil {
+		t.Fatal(err)
+	}
+	rec.AssertStatement(t, "update jobs set status = $1, attempts = 0, runat = $2, lockeduntil = null where id = $3 and status = $4", StatusQueued, mock.AnyArg, int64(7), StatusDead)
+}
@@ -0,0 +1,267 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+const (
+	HasOne     = "has_one"
+	HasMany    = "has_many"
+	ManyToMany = "many_to_many"
+)
+
+// Relation is defined by the relation tag, for example:
+//
+//	Items []Item `relation:"has_many;table:order_items;foreign_key:order_id"`
+//	Tags  []Tag  `relation:"many_to_many;table:tags;join_table:order_tags;foreign_key:order_id;association_foreign_key:tag_id"`
+//
+// For has_one and has_many, foreign_key is the column of the child table, which refers to the "references" column of the parent (the primary key by default).
+// For many_to_many, foreign_key and association_foreign_key are the columns of the join table, which refer to the parent and to the "association_references" column of the child (the primary key by default).
+type Relation struct {
+	Name                  string
+	Kind                  string
+	Index                 int
+	Table                 string
+	ForeignKey            string
+	References            string
+	JoinTable             string
+	AssociationForeignKey string
+	AssociationReferences string
+	ModelType             reflect.Type
+	Ptr                   bool
+	Fields                string
+	fieldsIndex           map[string]int
+	referenceIndex        int
+}
+
+var relations sync.Map
+
+func GetRelations(modelType reflect.Type) (map[string]*Relation, error) {
+	if v, ok := relations.Load(modelType); ok {
+		return v.(map[string]*Relation), nil
+	}
+	rs := make(map[string]*Relation)
+	schema := CreateSchema(modelType)
+	for i := 0; i < modelType.NumField(); i++ {
+		field := modelType.Field(i)
+		tag, ok := field.Tag.Lookup("relation")
+		if !ok {
+			continue
+		}
+		r := &Relation{Name: field.Name, Index: i, Kind: strings.TrimSpace(strings.Split(tag, ";")[0])}
+		r.Table, _ = FindTag(tag, "table")
+		r.ForeignKey, _ = FindTag(tag, "foreign_key")
+		r.References, _ = FindTag(tag, "references")
+		r.JoinTable, _ = FindTag(tag, "join_table")
+		r.AssociationForeignKey, _ = FindTag(tag, "association_foreign_key")
+		r.AssociationReferences, _ = FindTag(tag, "association_references")
+		t := field.Type
+		switch r.Kind {
+		case HasOne:
+			if t.Kind() == reflect.Ptr {
+				r.Ptr = true
+				t = t.Elem()
+			}
+		case HasMany, ManyToMany:
+			if t.Kind() != reflect.Slice {
+				return nil, fmt.Errorf("%s of %s must be a slice", r.Name, modelType.Name())
+			}
+			t = t.Elem()
+			if t.Kind() == reflect.Ptr {
+				r.Ptr = true
+				t = t.Elem()
+			}
+		default:
+			return nil, fmt.Errorf("invalid relation '%s' of %s", r.Kind, r.Name)
+		}
+		if t.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("%s of %s must be a struct", r.Name, modelType.Name())
+		}
+		r.ModelType = t
+		if len(r.Table) == 0 || len(r.ForeignKey) == 0 {
+			return nil, fmt.Errorf("require table and foreign_key of %s", r.Name)
+		}
+		if r.Kind == ManyToMany && (len(r.JoinTable) == 0 || len(r.AssociationForeignKey) == 0) {
+			return nil, fmt.Errorf("require join_table and association_foreign_key of %s", r.Name)
+		}
+		r.referenceIndex = -1
+		if len(r.References) == 0 {
+			if len(schema.Keys) != 1 {
+				return nil, fmt.Errorf("require references of %s", r.Name)
+			}
+			r.References = schema.Keys[0].Column
+			r.referenceIndex = schema.Keys[0].Index
+		} else {
+			for _, c := range schema.Columns {
+				if strings.EqualFold(c.Column, r.References) {
+					r.referenceIndex = c.Index
+				}
+			}
+		}
+		if r.referenceIndex < 0 {
+			return nil, fmt.Errorf("cannot find the column %s of %s", r.References, modelType.Name())
+		}
+		childSchema := CreateSchema(t)
+		if r.Kind == ManyToMany && len(r.AssociationReferences) == 0 {
+			if len(childSchema.Keys) != 1 {
+				return nil, fmt.Errorf("require association_references of %s", r.Name)
+			}
+			r.AssociationReferences = childSchema.Keys[0].Column
+		}
+		columns := make([]string, len(childSchema.SColumns))
+		for j, c := range childSchema.SColumns {
+			if r.Kind == ManyToMany {
+				columns[j] = "c." + c
+			} else {
+				columns[j] = c
+			}
+		}
+		r.Fields = strings.Join(columns, ",")
+		fieldsIndex, err := GetFieldsIndex(t)
+		if err != nil {
+			return nil, err
+		}
+		r.fieldsIndex = fieldsIndex
+		rs[r.Name] = r
+	}
+	v, _ := relations.LoadOrStore(modelType, rs)
+	return v.(map[string]*Relation), nil
+}
+
+// GetParamLimit returns the max number of the parameters of a statement. For Oracle, it is the max number of the expressions of an IN list.
+func GetParamLimit(driver string) int {
+	switch driver {
+	case DriverOracle:
+		return 1000
+	case DriverMssql:
+		return 2000
+	case DriverSqlite3:
+		return 999
+	default:
+		return 30000
+	}
+}
+
+func Preload[T any](ctx context.Context, db Executor, driver string, buildParam func(int) string, objs []T, names ...string) error {
+	return PreloadWithArray[T](ctx, db, driver, buildParam, nil, objs, names...)
+}
+
+// PreloadWithArray loads the relations of the objects, by one query per relation (per chunk of the parameter limit), and sets the relation fields.
+// The relation fields are reset before, so the relations can be loaded again.
+func PreloadWithArray[T any](ctx context.Context, db Executor, driver string, buildParam func(int) string, toArray func(interface{}) interface {
+	driver.Valuer
+	sql.Scanner
+}, objs []T, names ...string) error {
+	if len(objs) == 0 || len(names) == 0 {
+		return nil
+	}
+	var t T
+	modelType := reflect.TypeOf(t)
+	if modelType.Kind() != reflect.Struct {
+		return errors.New("T must be a struct")
+	}
+	rs, er0 := GetRelations(modelType)
+	if er0 != nil {
+		return er0
+	}
+	if buildParam == nil {
+		buildParam = GetBuildByDriver(driver)
+	}
+	values := reflect.ValueOf(objs)
+	for _, name := range names {
+		r, ok := rs[name]
+		if !ok {
+			return fmt.Errorf("cannot find the relation %s of %s", name, modelType.Name())
+		}
+		parents := make(map[string][]reflect.Value)
+		keys := make([]interface{}, 0)
+		for i := 0; i < values.Len(); i++ {
+			obj := values.Index(i)
+			// the children are appended, so the relation is reset, to load it again
+			field := obj.Field(r.Index)
+			field.Set(reflect.Zero(field.Type()))
+			v := reflect.Indirect(obj.Field(r.referenceIndex))
+			if !v.IsValid() {
+				continue
+			}
+			key := toKey(v.Interface())
+			if _, exist := parents[key]; !exist {
+				keys = append(keys, v.Interface())
+			}
+			parents[key] = append(parents[key], obj)
+		}
+		size := GetParamLimit(driver)
+		for i := 0; i < len(keys); i += size {
+			chunk := keys[i:Min(i+size, len(keys))]
+			if err := r.load(ctx, db, buildParam, toArray, chunk, parents); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *Relation) load(ctx context.Context, db Executor, buildParam func(int) string, toArray func(interface{}) interface {
+	driver.Valuer
+	sql.Scanner
+}, keys []interface{}, parents map[string][]reflect.Value) error {
+	params := make([]string, len(keys))
+	for i := range keys {
+		params[i] = buildParam(i + 1)
+	}
+	var query string
+	if r.Kind == ManyToMany {
+		query = fmt.Sprintf("select j.%s,%s from %s c join %s j on j.%s = c.%s where j.%s in (%s)",
+			r.ForeignKey, r.Fields, r.Table, r.JoinTable, r.AssociationForeignKey, r.AssociationReferences, r.ForeignKey, strings.Join(params, ","))
+	} else {
+		query = fmt.Sprintf("select %s,%s from %s where %s in (%s)", r.ForeignKey, r.Fields, r.Table, r.ForeignKey, strings.Join(params, ","))
+	}
+	rows, er1 := db.QueryContext(ctx, query, keys...)
+	if er1 != nil {
+		return er1
+	}
+	defer rows.Close()
+	columns, er2 := GetColumns(rows.Columns())
+	if er2 != nil {
+		return er2
+	}
+	for rows.Next() {
+		var key interface{}
+		child := reflect.New(r.ModelType)
+		c, swapValues := StructScanAndIgnore(child.Interface(), columns, r.fieldsIndex, toArray, 0)
+		if err := rows.Scan(append([]interface{}{&key}, c...)...); err != nil {
+			return err
+		}
+		SwapValuesToBool(child.Interface(), &swapValues)
+		for _, parent := range parents[toKey(key)] {
+			field := parent.Field(r.Index)
+			x := child
+			if !r.Ptr {
+				x = child.Elem()
+			}
+			if r.Kind == HasOne {
+				field.Set(x)
+			} else {
+				field.Set(reflect.Append(field, x))
+			}
+		}
+	}
+	if er3 := rows.Err(); er3 != nil {
+		return er3
+	}
+	return rows.Close()
+}
+
+func toKey(v interface{}) string {
+	if b, ok := v.([]byte); ok {
+		return string(b)
+	}
+	return fmt.Sprint(v)
+}
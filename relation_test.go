@@ -0,0 +1,90 @@
+package sql
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/core-go/sql/mock"
+)
+
+type testItem struct {
+	Id   string `gorm:"column:id;primary_key"`
+	Name string `gorm:"column:name"`
+}
+type testTag struct {
+	Id   string `gorm:"column:id;primary_key"`
+	Name string `gorm:"column:name"`
+}
+type testOrder struct {
+	Id    string     `gorm:"column:id;primary_key"`
+	Items []testItem `relation:"has_many;table:order_items;foreign_key:order_id"`
+	Tags  []*testTag `relation:"many_to_many;table:tags;join_table:order_tags;foreign_key:order_id;association_foreign_key:tag_id"`
+	Main  *testItem  `relation:"has_one;table:order_items;foreign_key:main_of"`
+}
+
+func TestPreloadHasMany(t *testing.T) {
+	db, rec := mock.New("postgres")
+	rec.ExpectRegexp("from order_items").WillReturnRows([]string{"order_id", "id", "name"},
+		[]interface{}{"1", "a", "A"}, []interface{}{"2", "b", "B"}, []interface{}{"1", "c", "C"}).Times(2)
+	orders := []testOrder{{Id: "1"}, {Id: "2"}, {Id: "3"}}
+	for i := 0; i < 2; i++ {
+		if err := Preload[testOrder](context.Background(), db, DriverPostgres, nil, orders, "Items"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rec.AssertStatement(t, "select order_id,id,name from order_items where order_id in ($1,$2,$3)", "1", "2", "3")
+	if len(orders[0].Items) != 2 || orders[0].Items[0].Id != "a" || orders[0].Items[1].Id != "c" {
+		t.Errorf("expected the items a and c of order 1 once, got %v", orders[0].Items)
+	}
+	if len(orders[1].Items) != 1 || orders[1].Items[0].Id != "b" {
+		t.Errorf("expected the item b of order 2, got %v", orders[1].Items)
+	}
+	if len(orders[2].Items) != 0 {
+		t.Errorf("expected no item of order 3, got %v", orders[2].Items)
+	}
+}
+
+func TestPreloadManyToManyAndHasOne(t *testing.T) {
+	db, rec := mock.New("mysql")
+	rec.ExpectRegexp("from tags").WillReturnRows([]string{"order_id", "id", "name"},
+		[]interface{}{"1", "x", "X"}, []interface{}{"2", "x", "X"})
+	rec.ExpectRegexp("from order_items").WillReturnRows([]string{"main_of", "id", "name"}, []interface{}{"2", "m", "M"})
+	orders := []testOrder{{Id: "1"}, {Id: "2"}, {Id: "1"}}
+	if err := Preload[testOrder](context.Background(), db, DriverMysql, nil, orders, "Tags", "Main"); err != nil {
+		t.Fatal(err)
+	}
+	rec.AssertStatement(t, "select j.order_id,c.id,c.name from tags c join order_tags j on j.tag_id = c.id where j.order_id in (?,?)", "1", "2")
+	rec.AssertStatement(t, "select main_of,id,name from order_items where main_of in (?,?)", "1", "2")
+	if len(orders[0].Tags) != 1 || len(orders[1].Tags) != 1 || len(orders[2].Tags) != 1 || orders[2].Tags[0].Id != "x" {
+		t.Errorf("expected the tag x of every order, got %v %v %v", orders[0].Tags, orders[1].Tags, orders[2].Tags)
+	}
+	if orders[0].Main != nil || orders[1].Main == nil || orders[1].Main.Id != "m" {
+		t.Errorf("expected the main item of order 2 only, got %v %v", orders[0].Main, orders[1].Main)
+	}
+}
+
+func TestPreloadChunksByParamLimit(t *testing.T) {
+	db, rec := mock.New("sqlite3")
+	orders := make([]testOrder, 1500)
+	for i := range orders {
+		orders[i].Id = strconv.Itoa(i)
+	}
+	if err := Preload[testOrder](context.Background(), db, DriverSqlite3, nil, orders, "Items"); err != nil {
+		t.Fatal(err)
+	}
+	var sizes []int
+	for _, s := range rec.Statements() {
+		sizes = append(sizes, len(s.Args))
+	}
+	if len(sizes) != 2 || sizes[0] != 999 || sizes[1] != 501 {
+		t.Fatalf("expected the chunks of 999 and 501 keys, got %v", sizes)
+	}
+}
+
+func TestPreloadUnknownRelation(t *testing.T) {
+	db, _ := mock.New("postgres")
+	if err := Preload[testOrder](context.Background(), db, DriverPostgres, nil, []testOrder{{Id: "1"}}, "Unknown"); err == nil {
+		t.Fatal("expected the error of the unknown relation")
+	}
+}
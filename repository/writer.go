@@ -68,7 +68,7 @@ func NewSqlWriterWithVersionAndArray[T any](db *sql.DB, tableName string, versio
 	if len(primaryKeys) == 0 {
 		return nil, fmt.Errorf("require primary key for table '%s'", tableName)
 	}
-	schema := q.CreateSchema(modelType)
+	schema := q.CreateSchema(modelType, drivr)
 	jsonColumnMapT := q.MakeJsonColumnMap(modelType)
 	jsonColumnMap := q.GetWritableColumns(schema.Fields, jsonColumnMapT)
 	adapter := &Writer[T]{DB: db, Table: tableName, Schema: schema, Keys: primaryKeys, JsonColumnMap: jsonColumnMap, BuildParam: buildParam, Driver: drivr, BoolSupport: boolSupport, ToArray: toArray, TxKey: "tx", versionIndex: -1}
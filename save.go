@@ -64,6 +64,9 @@ func BuildToSaveWithSchema(table string, model interface{}, driver string, build
 					fieldValue = reflect.Indirect(reflect.ValueOf(fieldValue)).Interface()
 				}
 			}
+			if !isNil {
+				fieldValue, isNil = ToDBValueOrNull(fdb, fieldValue)
+			}
 			if !isNil {
 				iCols = append(iCols, fdb.Column)
 				v, ok := GetDBValue(fieldValue, boolSupport, fdb.Scale)
@@ -112,6 +115,9 @@ func BuildToSaveWithSchema(table string, model interface{}, driver string, build
 						fieldValue = reflect.Indirect(reflect.ValueOf(fieldValue)).Interface()
 					}
 				}
+				if !isNil {
+					fieldValue, isNil = ToDBValueOrNull(fdb, fieldValue)
+				}
 				if isNil {
 					setColumns = append(setColumns, fdb.Column+"=null")
 				} else {
@@ -205,6 +211,9 @@ func BuildToSaveWithSchema(table string, model interface{}, driver string, build
 					fieldValue = reflect.Indirect(reflect.ValueOf(fieldValue)).Interface()
 				}
 			}
+			if !isNil {
+				fieldValue, isNil = ToDBValueOrNull(fdb, fieldValue)
+			}
 			iCols = append(iCols, fdb.Column)
 			if isNil {
 				values = append(values, "null")
@@ -276,6 +285,9 @@ func BuildToSaveWithSchema(table string, model interface{}, driver string, build
 						fieldValue = reflect.Indirect(reflect.ValueOf(fieldValue)).Interface()
 					}
 				}
+				if !isNil {
+					fieldValue, isNil = ToDBValueOrNull(fdb, fieldValue)
+				}
 				if isNil {
 					variables = append(variables, "null "+tkey)
 				} else {
@@ -341,6 +353,9 @@ func BuildToSaveWithSchema(table string, model interface{}, driver string, build
 						fieldValue = reflect.Indirect(reflect.ValueOf(fieldValue)).Interface()
 					}
 				}
+				if !isNil {
+					fieldValue, isNil = ToDBValueOrNull(fdb, fieldValue)
+				}
 				if isNil {
 					variables = append(variables, "null")
 				} else {
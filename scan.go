@@ -43,6 +43,7 @@ func GetFieldByJson(modelType reflect.Type, jsonName string) (int, string, strin
 
 func Count(ctx context.Context, db Executor, sql string, values ...interface{}) (int64, error) {
 	var total int64
+	sql, values = ExpandIn(sql, ConvertArgs(values)...)
 	row := db.QueryRowContext(ctx, sql, values...)
 	err2 := row.Scan(&total)
 	if err2 != nil {
@@ -55,6 +56,7 @@ func Query(ctx context.Context, db Executor, fieldsIndex map[string]int, results
 	return QueryWithArray(ctx, db, fieldsIndex, results, nil, sql, values...)
 }
 func Exec(ctx context.Context, db Executor, query string, args ...interface{}) (int64, error) {
+	query, args = ExpandIn(query, ConvertArgs(args)...)
 	res, err := db.ExecContext(ctx, query, args...)
 	return RowsAffected(res, err)
 }
@@ -95,6 +97,7 @@ func QueryContextWithArray(ctx context.Context, db Executor, fieldsIndex map[str
 }, query string, values ...interface{}) error {
 	var rows *sql.Rows
 	var er1 error
+	query, values = ExpandIn(query, ConvertArgs(values)...)
 	rows, er1 = db.QueryContext(ctx, query, values...)
 	if er1 != nil {
 		return er1
@@ -122,6 +125,7 @@ func QueryWithArray(ctx context.Context, db Executor, fieldsIndex map[string]int
 	driver.Valuer
 	sql.Scanner
 }, sql string, values ...interface{}) error {
+	sql, values = ExpandIn(sql, ConvertArgs(values)...)
 	rows, er1 := db.QueryContext(ctx, sql, values...)
 	if er1 != nil {
 		return er1
@@ -350,12 +354,17 @@ func StructScanAndIgnore(s interface{}, columns []string, fieldsIndex map[string
 		modelType := reflect.TypeOf(s).Elem()
 		swapValues = make(map[int]interface{}, 0)
 		maps := reflect.Indirect(reflect.ValueOf(s))
+		scanners := getFieldScanners("", modelType)
 
 		if columns == nil {
 			for i := 0; i < maps.NumField(); i++ {
 				tagBool := modelType.Field(i).Tag.Get("true")
 				if tagBool == "" {
-					r = append(r, maps.Field(i).Addr().Interface())
+					x := maps.Field(i).Addr().Interface()
+					if scanner, ok := scanners[i].toScanner(modelType.Field(i), x); ok {
+						x = scanner
+					}
+					r = append(r, x)
 				} else {
 					var str string
 					swapValues[i] = reflect.New(reflect.TypeOf(str)).Elem().Addr().Interface()
@@ -392,7 +401,15 @@ func StructScanAndIgnore(s interface{}, columns []string, fieldsIndex map[string
 			x := valueField.Addr().Interface()
 			tagBool := modelField.Tag.Get("true")
 			if tagBool == "" {
-				if toArray != nil && valueField.Kind() == reflect.Slice {
+				var scanner interface{}
+				if len(modelField.Index) == 1 {
+					scanner, ok = scanners[modelField.Index[0]].toScanner(modelField, x)
+				} else {
+					scanner, ok = ToScanner(modelField, x)
+				}
+				if ok {
+					x = scanner
+				} else if toArray != nil && valueField.Kind() == reflect.Slice {
 					x = toArray(x)
 				}
 				r = append(r, x)
@@ -4,7 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"regexp"
 	"strings"
+	"sync"
+
+	q "github.com/core-go/sql"
 )
 
 type Sequence func(context.Context, string) (int64, error)
@@ -15,7 +19,25 @@ type SequenceAdapter struct {
 	Table      string
 	Sequence   string
 	BuildParam func(i int) string
+	Driver     string
+	// BlockSize is the number of the values reserved per round trip, which are handed out from memory. The values are not reserved in blocks if it is 0 or 1.
+	// For the native sequences, the sequence must be created with "increment by" BlockSize.
+	BlockSize int64
+	// Native uses the sequences of the database, named by seqName: nextval of Postgres, NEXTVAL of Oracle, NEXT VALUE FOR of MS SQL
+	Native bool
+	mu     sync.Mutex
+	blocks map[string]*block
+}
+
+// block is the reserved values from next to end - 1
+type block struct {
+	mu   sync.Mutex
+	next int64
+	end  int64
 }
+
+var sequenceName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$#.]*$`)
+
 func NewSequenceRepository(db *sql.DB, buildParam func(int) string, options ...string) *SequenceAdapter {
 	return NewSequenceAdapter(db, buildParam, options...)
 }
@@ -42,25 +64,196 @@ func NewSequenceAdapter(db *sql.DB, buildParam func(int) string, options ...stri
 		Table:      strings.ToLower(table),
 		Sequence:   strings.ToLower(sequence),
 		BuildParam: buildParam,
+		Driver:     q.GetDriver(db),
+		blocks:     make(map[string]*block),
 	}
 }
+
+// NewSequenceAdapterWithBlock creates a SequenceAdapter, which reserves blockSize values per round trip. It is safe across instances, because each block is reserved in the database.
+func NewSequenceAdapterWithBlock(db *sql.DB, buildParam func(int) string, blockSize int64, options ...string) *SequenceAdapter {
+	s := NewSequenceAdapter(db, buildParam, options...)
+	s.BlockSize = blockSize
+	return s
+}
+
+// NewNativeSequenceAdapter creates a SequenceAdapter of the native sequences. If blockSize > 1, the sequences must be created with "increment by" blockSize.
+func NewNativeSequenceAdapter(db *sql.DB, buildParam func(int) string, options ...int64) *SequenceAdapter {
+	s := NewSequenceAdapter(db, buildParam)
+	s.Native = true
+	if len(options) > 0 {
+		s.BlockSize = options[0]
+	}
+	return s
+}
 func (s *SequenceAdapter) Next(ctx context.Context, seqName string) (int64, error) {
-	seq, err := s.next(ctx, seqName)
+	if s.BlockSize <= 1 {
+		return s.reserve(ctx, seqName, 1)
+	}
+	b := s.getBlock(seqName)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.next >= b.end {
+		first, err := s.reserve(ctx, seqName, s.BlockSize)
+		if err != nil {
+			return -1, err
+		}
+		b.next, b.end = first, first+s.BlockSize
+	}
+	seq := b.next
+	b.next++
+	return seq, nil
+}
+
+// NextN returns count values, such as the ids of a batch. The values of the table are consecutive, except the values left in the current block.
+func (s *SequenceAdapter) NextN(ctx context.Context, seqName string, count int) ([]int64, error) {
+	seqs := make([]int64, 0, count)
+	if count <= 0 {
+		return seqs, nil
+	}
+	if s.BlockSize > 1 {
+		b := s.getBlock(seqName)
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for ; b.next < b.end && len(seqs) < count; b.next++ {
+			seqs = append(seqs, b.next)
+		}
+		if s.Native {
+			for len(seqs) < count {
+				first, err := s.reserve(ctx, seqName, s.BlockSize)
+				if err != nil {
+					return seqs, err
+				}
+				b.next, b.end = first, first+s.BlockSize
+				for ; b.next < b.end && len(seqs) < count; b.next++ {
+					seqs = append(seqs, b.next)
+				}
+			}
+			return seqs, nil
+		}
+	} else if s.Native {
+		return s.nativeNextN(ctx, seqName, count)
+	}
+	n := count - len(seqs)
+	if n == 0 {
+		return seqs, nil
+	}
+	first, err := s.reserve(ctx, seqName, int64(n))
 	if err != nil {
-		return seq, err
+		return seqs, err
+	}
+	for i := 0; i < n; i++ {
+		seqs = append(seqs, first+int64(i))
+	}
+	return seqs, nil
+}
+func (s *SequenceAdapter) getBlock(seqName string) *block {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.blocks == nil {
+		s.blocks = make(map[string]*block)
+	}
+	b, ok := s.blocks[seqName]
+	if !ok {
+		b = &block{}
+		s.blocks[seqName] = b
+	}
+	return b
+}
+
+// reserve reserves count values, and returns the first one. For the native sequences, count must be 1 or BlockSize.
+func (s *SequenceAdapter) reserve(ctx context.Context, seqName string, count int64) (int64, error) {
+	if s.Native {
+		return s.nativeNext(ctx, seqName)
 	}
 	for {
-		if seq == -2 {
-			seq, err := s.next(ctx, seqName)
-			if err != nil {
-				return seq, err
-			}
-		} else {
+		seq, err := s.next(ctx, seqName, count)
+		if err != nil {
+			return seq, err
+		}
+		if seq != -2 {
 			return seq, nil
 		}
+		if err = ctx.Err(); err != nil {
+			return -1, err
+		}
+	}
+}
+func (s *SequenceAdapter) nativeNext(ctx context.Context, seqName string) (int64, error) {
+	var query string
+	var args []interface{}
+	switch s.Driver {
+	case q.DriverPostgres:
+		query, args = "select nextval($1)", []interface{}{seqName}
+	case q.DriverOracle, q.DriverMssql:
+		if !sequenceName.MatchString(seqName) {
+			return -1, fmt.Errorf("invalid sequence name '%s'", seqName)
+		}
+		if s.Driver == q.DriverOracle {
+			query = fmt.Sprintf("select %s.nextval from dual", seqName)
+		} else {
+			query = fmt.Sprintf("select next value for %s", seqName)
+		}
+	default:
+		return -1, fmt.Errorf("native sequence is not supported for '%s'", s.Driver)
+	}
+	var seq int64
+	if err := s.DB.QueryRowContext(ctx, query, args...).Scan(&seq); err != nil {
+		return -1, err
+	}
+	return seq, nil
+}
+
+// nativeNextN gets count values of the native sequence in a round trip.
+func (s *SequenceAdapter) nativeNextN(ctx context.Context, seqName string, count int) ([]int64, error) {
+	var query string
+	var args []interface{}
+	switch s.Driver {
+	case q.DriverPostgres:
+		query, args = "select nextval($1) from generate_series(1, $2)", []interface{}{seqName, count}
+	case q.DriverOracle:
+		if !sequenceName.MatchString(seqName) {
+			return nil, fmt.Errorf("invalid sequence name '%s'", seqName)
+		}
+		query = fmt.Sprintf("select %s.nextval from dual connect by level <= %d", seqName, count)
+	case q.DriverMssql:
+		return s.nativeRange(ctx, seqName, count)
+	default:
+		return nil, fmt.Errorf("native sequence is not supported for '%s'", s.Driver)
+	}
+	rows, err := s.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	seqs := make([]int64, 0, count)
+	for rows.Next() {
+		var seq int64
+		if err = rows.Scan(&seq); err != nil {
+			return nil, err
+		}
+		seqs = append(seqs, seq)
+	}
+	return seqs, rows.Err()
+}
+
+// nativeRange gets count values of the MS SQL sequence by sp_sequence_get_range, because "next value for" is not allowed with "top".
+// The values are first, first + increment, ..., so the sequence must not cycle within the range.
+func (s *SequenceAdapter) nativeRange(ctx context.Context, seqName string, count int) ([]int64, error) {
+	query := "declare @first sql_variant, @increment sql_variant; " +
+		"exec sp_sequence_get_range @sequence_name = @p1, @range_size = @p2, @range_first_value = @first output, @sequence_increment = @increment output; " +
+		"select cast(@first as bigint), cast(@increment as bigint)"
+	var first, increment int64
+	if err := s.DB.QueryRowContext(ctx, query, seqName, count).Scan(&first, &increment); err != nil {
+		return nil, err
+	}
+	seqs := make([]int64, count)
+	for i := range seqs {
+		seqs[i] = first + int64(i)*increment
 	}
+	return seqs, nil
 }
-func (s *SequenceAdapter) next(ctx context.Context, seqName string) (int64, error) {
+// next reserves count values, and returns the first one, or -2 if the sequence is updated by another instance.
+func (s *SequenceAdapter) next(ctx context.Context, seqName string, count int64) (int64, error) {
 	query := fmt.Sprintf(`select %s from %s where %s = %s`, s.Sequence, s.Tables, s.Table, s.BuildParam(1))
 	rows, err := s.DB.QueryContext(ctx, query, seqName)
 	if err != nil {
@@ -72,7 +265,7 @@ func (s *SequenceAdapter) next(ctx context.Context, seqName string) (int64, erro
 		if err := rows.Scan(&seq); err != nil {
 			return -1, err
 		}
-		updateSql := fmt.Sprintf(`update %s set %s = %s + 1 where %s = %s and %s = %d`, s.Tables, s.Sequence, s.Sequence, s.Table, s.BuildParam(1), s.Sequence, seq)
+		updateSql := fmt.Sprintf(`update %s set %s = %s + %d where %s = %s and %s = %d`, s.Tables, s.Sequence, s.Sequence, count, s.Table, s.BuildParam(1), s.Sequence, seq)
 		res, err := s.DB.ExecContext(ctx, updateSql, seqName)
 		if err != nil {
 			return -1, err
@@ -86,11 +279,11 @@ func (s *SequenceAdapter) next(ctx context.Context, seqName string) (int64, erro
 		}
 		return seq, nil
 	} else {
-		insertSql := fmt.Sprintf(`insert into %s (%s, %s) values (%s, 2)`, s.Tables, s.Table, s.Sequence, s.BuildParam(1))
+		insertSql := fmt.Sprintf(`insert into %s (%s, %s) values (%s, %d)`, s.Tables, s.Table, s.Sequence, s.BuildParam(1), count+1)
 		_, err = s.DB.ExecContext(ctx, insertSql, seqName)
 		if err != nil {
 			x := strings.ToLower(err.Error())
-			if strings.Index(x, "unique constraint") >= 0 || strings.Index(x, "Violation of PRIMARY KEY constraint") >= 0 {
+			if q.ClassifyError(err) == q.ErrorDuplicate || strings.Index(x, "violation of primary key constraint") >= 0 {
 				return -2, nil
 			}
 			return -1, err
@@ -99,6 +292,9 @@ func (s *SequenceAdapter) next(ctx context.Context, seqName string) (int64, erro
 	}
 }
 func (s *SequenceAdapter) Reset(ctx context.Context, id string) (int64, error) {
+	s.mu.Lock()
+	delete(s.blocks, id)
+	s.mu.Unlock()
 	updateSql := fmt.Sprintf(`update %s set %s = 1 where %s = %s`, s.Tables, s.Sequence, s.Table, s.BuildParam(1))
 	res, err := s.DB.ExecContext(ctx, updateSql, id)
 	if err != nil {
@@ -0,0 +1,160 @@
+package sequence
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	q "github.com/core-go/sql"
+	"github.com/core-go/sql/mock"
+)
+
+func newAdapter(dialect string, blockSize int64) (*SequenceAdapter, *mock.Recorder) {
+	db, rec := mock.New(dialect)
+	return NewSequenceAdapterWithBlock(db, q.GetBuild(db), blockSize), rec
+}
+func countQueries(rec *mock.Recorder, prefix string) int {
+	n := 0
+	for _, s := range rec.Statements() {
+		if strings.HasPrefix(s.Query, prefix) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestNextReservesBlock(t *testing.T) {
+	s, rec := newAdapter("postgres", 3)
+	rec.ExpectRegexp("^select sequence").WillReturnRows([]string{"sequence"}, []interface{}{int64(10)})
+	rec.ExpectRegexp("^select sequence").WillReturnRows([]string{"sequence"}, []interface{}{int64(20)})
+	var seqs []int64
+	for i := 0; i < 4; i++ {
+		seq, err := s.Next(context.Background(), "users")
+		if err != nil {
+			t.Fatal(err)
+		}
+		seqs = append(seqs, seq)
+	}
+	if !reflect.DeepEqual(seqs, []int64{10, 11, 12, 20}) {
+		t.Fatalf("expected the values of 2 blocks, got %v", seqs)
+	}
+	rec.AssertStatement(t, "select sequence from sequences where table = $1", "users")
+	rec.AssertStatement(t, "update sequences set sequence = sequence + 3 where table = $1 and sequence = 10", "users")
+	rec.AssertStatement(t, "update sequences set sequence = sequence + 3 where table = $1 and sequence = 20", "users")
+	if n := countQueries(rec, "select"); n != 2 {
+		t.Errorf("expected 2 round trips, got %d", n)
+	}
+}
+
+func TestNextRetriesIfBlockIsTaken(t *testing.T) {
+	s, rec := newAdapter("mysql", 0)
+	rec.ExpectRegexp("^select sequence").WillReturnRows([]string{"sequence"}, []interface{}{int64(10)})
+	rec.ExpectRegexp("sequence = 10$").WillReturnResult(0, 0)
+	rec.ExpectRegexp("^select sequence").WillReturnRows([]string{"sequence"}, []interface{}{int64(11)})
+	seq, err := s.Next(context.Background(), "users")
+	if err != nil || seq != 11 {
+		t.Fatalf("expected 11 after the value 10 is taken by another instance, got %d %v", seq, err)
+	}
+	rec.AssertStatement(t, "update sequences set sequence = sequence + 1 where table = ? and sequence = 11", "users")
+}
+
+func TestNextInsertsNewSequence(t *testing.T) {
+	s, rec := newAdapter("postgres", 3)
+	seq, err := s.Next(context.Background(), "users")
+	if err != nil || seq != 1 {
+		t.Fatalf("expected 1, got %d %v", seq, err)
+	}
+	rec.AssertStatement(t, "insert into sequences (table, sequence) values ($1, 4)", "users")
+}
+
+func TestNextNUsesBlockThenReserves(t *testing.T) {
+	s, rec := newAdapter("postgres", 3)
+	rec.ExpectRegexp("^select sequence").WillReturnRows([]string{"sequence"}, []interface{}{int64(1)})
+	rec.ExpectRegexp("^select sequence").WillReturnRows([]string{"sequence"}, []interface{}{int64(4)})
+	if _, err := s.Next(context.Background(), "users"); err != nil {
+		t.Fatal(err)
+	}
+	seqs, err := s.NextN(context.Background(), "users", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(seqs, []int64{2, 3, 4, 5, 6}) {
+		t.Fatalf("expected the values left in the block, then 3 new values, got %v", seqs)
+	}
+	rec.AssertStatement(t, "update sequences set sequence = sequence + 3 where table = $1 and sequence = 4", "users")
+}
+
+func TestNativeNextByDialect(t *testing.T) {
+	tests := []struct {
+		dialect string
+		query   string
+		args    []interface{}
+	}{
+		{"postgres", "select nextval($1)", []interface{}{"users_seq"}},
+		{"oracle", "select users_seq.nextval from dual", nil},
+		{"mssql", "select next value for users_seq", nil},
+	}
+	for _, tt := range tests {
+		db, rec := mock.New(tt.dialect)
+		rec.Expect(tt.query).WillReturnRows([]string{"seq"}, []interface{}{int64(7)})
+		s := NewNativeSequenceAdapter(db, q.GetBuild(db))
+		seq, err := s.Next(context.Background(), "users_seq")
+		if err != nil || seq != 7 {
+			t.Fatalf("%s: expected 7, got %d %v", tt.dialect, seq, err)
+		}
+		rec.AssertStatement(t, tt.query, tt.args...)
+	}
+	db, _ := mock.New("oracle")
+	if _, err := NewNativeSequenceAdapter(db, q.GetBuild(db)).Next(context.Background(), "users; drop table users"); err == nil {
+		t.Error("expected the error of the invalid sequence name")
+	}
+	db, _ = mock.New("sqlite3")
+	if _, err := NewNativeSequenceAdapter(db, q.GetBuild(db)).Next(context.Background(), "users_seq"); err == nil {
+		t.Error("expected the error of the unsupported driver")
+	}
+}
+
+func TestNativeNextNByDialect(t *testing.T) {
+	tests := []struct {
+		dialect  string
+		query    string
+		columns  []string
+		rows     [][]interface{}
+		expected []int64
+	}{
+		{"postgres", "select nextval($1) from generate_series(1, $2)", []string{"seq"}, [][]interface{}{{int64(1)}, {int64(2)}, {int64(3)}}, []int64{1, 2, 3}},
+		{"oracle", "select users_seq.nextval from dual connect by level <= 3", []string{"seq"}, [][]interface{}{{int64(1)}, {int64(2)}, {int64(3)}}, []int64{1, 2, 3}},
+		{"mssql", "declare @first sql_variant, @increment sql_variant; exec sp_sequence_get_range @sequence_name = @p1, @range_size = @p2, @range_first_value = @first output, @sequence_increment = @increment output; select cast(@first as bigint), cast(@increment as bigint)",
+			[]string{"first", "increment"}, [][]interface{}{{int64(100), int64(5)}}, []int64{100, 105, 110}},
+	}
+	for _, tt := range tests {
+		db, rec := mock.New(tt.dialect)
+		rec.Expect(tt.query).WillReturnRows(tt.columns, tt.rows...)
+		s := NewNativeSequenceAdapter(db, q.GetBuild(db))
+		seqs, err := s.NextN(context.Background(), "users_seq", 3)
+		if err != nil || !reflect.DeepEqual(seqs, tt.expected) {
+			t.Fatalf("%s: expected %v, got %v %v", tt.dialect, tt.expected, seqs, err)
+		}
+		if tt.dialect != "oracle" {
+			rec.AssertStatement(t, tt.query, "users_seq", 3)
+		}
+	}
+}
+
+func TestNativeNextNByBlock(t *testing.T) {
+	db, rec := mock.New("postgres")
+	rec.Expect("select nextval($1)").WillReturnRows([]string{"seq"}, []interface{}{int64(1)})
+	rec.Expect("select nextval($1)").WillReturnRows([]string{"seq"}, []interface{}{int64(11)})
+	s := NewNativeSequenceAdapter(db, q.GetBuild(db), 10)
+	seqs, err := s.NextN(context.Background(), "users_seq", 15)
+	if err != nil || len(seqs) != 15 || seqs[0] != 1 || seqs[9] != 10 || seqs[10] != 11 || seqs[14] != 15 {
+		t.Fatalf("expected 1 to 15, got %v %v", seqs, err)
+	}
+	if seq, _ := s.Next(context.Background(), "users_seq"); seq != 16 {
+		t.Errorf("expected 16 from the current block, got %d", seq)
+	}
+	if n := countQueries(rec, "select nextval"); n != 2 {
+		t.Errorf("expected 2 round trips, got %d", n)
+	}
+}
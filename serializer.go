@@ -0,0 +1,174 @@
+package sql
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+const SerializerJSON = "json"
+
+// JSON writes the value as a JSON text, which can be stored in a json, jsonb or text column. To scan, V must be a pointer.
+type JSON struct {
+	V interface{}
+}
+
+func (j JSON) Value() (driver.Value, error) {
+	if isNilValue(j.V) {
+		return nil, nil
+	}
+	b, err := json.Marshal(j.V)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+func (j JSON) Scan(src interface{}) error {
+	switch x := src.(type) {
+	case nil:
+		v := reflect.ValueOf(j.V).Elem()
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	case []byte:
+		return json.Unmarshal(x, j.V)
+	case string:
+		return json.Unmarshal([]byte(x), j.V)
+	default:
+		return fmt.Errorf("cannot scan %T into JSON", src)
+	}
+}
+
+func isNilValue(x interface{}) bool {
+	if x == nil {
+		return true
+	}
+	v := reflect.ValueOf(x)
+	switch v.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
+// ToDBValue returns the value to write of the field, by the encrypt tag, the serializer and the converter of the field.
+func ToDBValue(fdb *FieldDB, v interface{}) interface{} {
+	if len(fdb.Encrypt) > 0 && fdb.Encrypt != "false" {
+		return Encrypted{V: v, Deterministic: fdb.Encrypt == EncryptDeterministic}
+	}
+	if fdb.Serializer == SerializerJSON {
+		return JSON{V: v}
+	}
+	if fdb.Converter != nil && fdb.Converter.Value != nil {
+		return fdb.Converter.Value(v)
+	}
+	return v
+}
+
+// ToDBValueOrNull returns true if v is null, as IsNull. Otherwise, it returns the value to write of the field, as ToDBValue.
+// The value of a driver.Valuer is computed once, by GetNullValue, if the field has no encrypt tag, serializer or converter.
+func ToDBValueOrNull(fdb *FieldDB, v interface{}) (interface{}, bool) {
+	x, null := GetNullValue(v)
+	if null {
+		return nil, true
+	}
+	if hasDBValue(fdb) {
+		return ToDBValue(fdb, v), false
+	}
+	return x, false
+}
+func hasDBValue(fdb *FieldDB) bool {
+	return len(fdb.Encrypt) > 0 && fdb.Encrypt != "false" || fdb.Serializer == SerializerJSON || fdb.Converter != nil && fdb.Converter.Value != nil
+}
+
+// ToPatchValue returns the value to write of the field, as ToDBValue, for the values of a patch map, which are decoded from JSON.
+// Before the converter, the value is converted to the type of the field by JSON, such as float64 to int64 or string to time.Time.
+// If the value cannot be converted, the converter is not used.
+func ToPatchValue(fdb *FieldDB, v interface{}) interface{} {
+	if fdb.Converter == nil || fdb.Converter.Value == nil || len(fdb.Encrypt) > 0 && fdb.Encrypt != "false" || fdb.Serializer == SerializerJSON {
+		return ToDBValue(fdb, v)
+	}
+	if fdb.Type == nil {
+		return v
+	}
+	t := fdb.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if reflect.TypeOf(v) == t || reflect.TypeOf(v) == fdb.Type {
+		return fdb.Converter.Value(v)
+	}
+	b, er1 := json.Marshal(v)
+	if er1 != nil {
+		return v
+	}
+	p := reflect.New(t)
+	if er2 := json.Unmarshal(b, p.Interface()); er2 != nil {
+		return v
+	}
+	return fdb.Converter.Value(p.Elem().Interface())
+}
+
+// ToScanner returns the scanner of the field by the encrypt tag, the serializer of the gorm tag and the registered converters. x is the pointer to the field.
+// The rows do not expose the driver, so the converters are found as FindConverter with an empty driver.
+func ToScanner(field reflect.StructField, x interface{}) (interface{}, bool) {
+	return newFieldScanner("", field).toScanner(field, x)
+}
+
+// fieldScanner is how a field is scanned, which is resolved once for each type and driver by getFieldScanners.
+type fieldScanner struct {
+	encrypt   string
+	json      bool
+	converter *Converter
+}
+
+type fieldScannersKey struct {
+	driver string
+	t      reflect.Type
+}
+
+// fieldScanners caches the fieldScanner of each field by the type and the driver. It is cleared when a converter is registered.
+var fieldScanners sync.Map
+
+func newFieldScanner(driver string, field reflect.StructField) fieldScanner {
+	var f fieldScanner
+	if encrypt, ok := field.Tag.Lookup("encrypt"); ok && len(encrypt) > 0 && encrypt != "false" {
+		f.encrypt = encrypt
+	} else if serializer, ok := FindTag(field.Tag.Get("gorm"), "serializer"); ok && serializer == SerializerJSON {
+		f.json = true
+	} else if c := FindConverter(driver, field); c != nil && c.Scanner != nil {
+		f.converter = c
+	}
+	return f
+}
+func getFieldScanners(driver string, modelType reflect.Type) []fieldScanner {
+	key := fieldScannersKey{driver: driver, t: modelType}
+	if v, ok := fieldScanners.Load(key); ok {
+		return v.([]fieldScanner)
+	}
+	scanners := make([]fieldScanner, modelType.NumField())
+	for i := range scanners {
+		scanners[i] = newFieldScanner(driver, modelType.Field(i))
+	}
+	fieldScanners.Store(key, scanners)
+	return scanners
+}
+func clearFieldScanners() {
+	fieldScanners.Range(func(key, _ interface{}) bool {
+		fieldScanners.Delete(key)
+		return true
+	})
+}
+func (f fieldScanner) toScanner(field reflect.StructField, x interface{}) (interface{}, bool) {
+	if len(f.encrypt) > 0 {
+		return Encrypted{V: x, Deterministic: f.encrypt == EncryptDeterministic}, true
+	}
+	if f.json {
+		return JSON{V: x}, true
+	}
+	if f.converter != nil {
+		return newConverterScanner(f.converter, field, x), true
+	}
+	return x, false
+}
@@ -51,6 +51,7 @@ func GetTxId(ctx context.Context) *string {
 }
 
 func QueryMapWithTx(ctx context.Context, db *sql.Tx, transform func(s string) string, sql string, values ...interface{}) ([]map[string]interface{}, error) {
+	sql, values = ExpandIn(sql, ConvertArgs(values)...)
 	rows, er1 := db.QueryContext(ctx, sql, values...)
 	if er1 != nil {
 		return nil, er1
@@ -104,6 +105,7 @@ func QueryMapWithTx(ctx context.Context, db *sql.Tx, transform func(s string) st
 	return res, nil
 }
 func QueryMap(ctx context.Context, db Executor, transform func(s string) string, sql string, values ...interface{}) ([]map[string]interface{}, error) {
+	sql, values = ExpandIn(sql, ConvertArgs(values)...)
 	rows, er1 := db.QueryContext(ctx, sql, values...)
 	if er1 != nil {
 		return nil, er1
@@ -163,6 +165,7 @@ func QueryTxWithArray(ctx context.Context, tx *sql.Tx, fieldsIndex map[string]in
 	driver.Valuer
 	sql.Scanner
 }, sql string, values ...interface{}) error {
+	sql, values = ExpandIn(sql, ConvertArgs(values)...)
 	rows, er1 := tx.QueryContext(ctx, sql, values...)
 	if er1 != nil {
 		return er1
@@ -8,9 +8,10 @@ type Statement struct {
 }
 
 type JStatement struct {
-	Query  string        `yaml:"query" mapstructure:"query" json:"query,omitempty" gorm:"column:query" bson:"query,omitempty" dynamodbav:"query,omitempty" firestore:"query,omitempty"`
-	Params []interface{} `yaml:"params" mapstructure:"params" json:"params,omitempty" gorm:"column:params" bson:"params,omitempty" dynamodbav:"params,omitempty" firestore:"params,omitempty"`
-	Dates  []int         `yaml:"dates" mapstructure:"dates" json:"dates,omitempty" gorm:"column:dates" bson:"dates,omitempty" dynamodbav:"dates,omitempty" firestore:"dates,omitempty"`
+	Query  string                 `yaml:"query" mapstructure:"query" json:"query,omitempty" gorm:"column:query" bson:"query,omitempty" dynamodbav:"query,omitempty" firestore:"query,omitempty"`
+	Params []interface{}          `yaml:"params" mapstructure:"params" json:"params,omitempty" gorm:"column:params" bson:"params,omitempty" dynamodbav:"params,omitempty" firestore:"params,omitempty"`
+	Dates  []int                  `yaml:"dates" mapstructure:"dates" json:"dates,omitempty" gorm:"column:dates" bson:"dates,omitempty" dynamodbav:"dates,omitempty" firestore:"dates,omitempty"`
+	Named  map[string]interface{} `yaml:"named" mapstructure:"named" json:"named,omitempty" gorm:"column:named" bson:"named,omitempty" dynamodbav:"named,omitempty" firestore:"named,omitempty"`
 }
 func BuildStatement(query string, values ...interface{}) *JStatement {
 	stm := JStatement{Query: query}
@@ -0,0 +1,360 @@
+package sql
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Upsert defines the conflict target and the update set of BuildToUpsert.
+// In Expressions and Where, "excluded.col" is the incoming value and "target.col" is the current value, such as "target.count + excluded.count".
+// They are rewritten for the dialect: excluded for Postgres and SQLite, values(col) for My SQL, the source of MERGE for Oracle and MS SQL.
+type Upsert struct {
+	// Conflict is the columns of the unique key, which is the primary key by default. My SQL uses all the unique keys of the table, so it is ignored.
+	Conflict []string
+	// Include is the columns to update. By default, all the columns which are not keys and can be updated.
+	Include []string
+	// Exclude is the columns not to update.
+	Exclude []string
+	// Expressions is the update expressions by column, instead of the incoming values.
+	Expressions map[string]string
+	// Where only updates the row if the condition is true, such as "excluded.updated_at > target.updated_at".
+	Where string
+	// Ignore does not update the existing rows, and only inserts the new rows.
+	Ignore bool
+}
+
+var (
+	excludedRef = regexp.MustCompile(`(?i)\bexcluded\.([a-z0-9_]+)`)
+	targetRef   = regexp.MustCompile(`(?i)\btarget\.([a-z0-9_]+)`)
+)
+
+func BuildToUpsert(table string, model interface{}, driver string, upsert Upsert, options ...*Schema) (string, []interface{}, error) {
+	return BuildToUpsertWithArray(table, model, driver, upsert, nil, options...)
+}
+func BuildToUpsertWithArray(table string, model interface{}, driver string, upsert Upsert, toArray func(interface{}) interface {
+	driver.Valuer
+	sql.Scanner
+}, options ...*Schema) (string, []interface{}, error) {
+	v := reflect.ValueOf(model)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	models := reflect.MakeSlice(reflect.SliceOf(v.Type()), 1, 1)
+	models.Index(0).Set(v)
+	return BuildToUpsertBatchWithArray(table, models.Interface(), driver, upsert, toArray, options...)
+}
+func BuildToUpsertBatch(table string, models interface{}, driver string, upsert Upsert, options ...*Schema) (string, []interface{}, error) {
+	return BuildToUpsertBatchWithArray(table, models, driver, upsert, nil, options...)
+}
+
+// BuildToUpsertBatchWithArray builds a single statement to upsert all the models. For Postgres, the models must not have the same conflict values.
+func BuildToUpsertBatchWithArray(table string, models interface{}, driver string, upsert Upsert, toArray func(interface{}) interface {
+	driver.Valuer
+	sql.Scanner
+}, options ...*Schema) (string, []interface{}, error) {
+	return buildToUpsertBatch(table, models, driver, upsert, toArray, nil, options...)
+}
+
+// BuildToInsertIgnoreBatch builds a single statement to insert the models and to skip the duplicate rows:
+// "on conflict do nothing" for Postgres and SQLite, "insert ignore" for My SQL, and "merge" on the primary key for Oracle and MS SQL.
+func BuildToInsertIgnoreBatch(table string, models interface{}, driver string, toArray func(interface{}) interface {
+	driver.Valuer
+	sql.Scanner
+}, buildParam func(int) string, options ...*Schema) (string, []interface{}, error) {
+	return buildToUpsertBatch(table, models, driver, Upsert{Ignore: true}, toArray, buildParam, options...)
+}
+func buildToUpsertBatch(table string, models interface{}, driver string, upsert Upsert, toArray func(interface{}) interface {
+	driver.Valuer
+	sql.Scanner
+}, buildParam func(int) string, options ...*Schema) (string, []interface{}, error) {
+	s := reflect.Indirect(reflect.ValueOf(models))
+	if s.Kind() != reflect.Slice {
+		return "", nil, fmt.Errorf("models must be a slice")
+	}
+	slen := s.Len()
+	if slen <= 0 {
+		return "", nil, nil
+	}
+	var schema *Schema
+	if len(options) > 0 && options[0] != nil {
+		schema = options[0]
+	} else {
+		modelType := reflect.Indirect(s.Index(0)).Type()
+		if modelType.Kind() == reflect.Interface {
+			modelType = reflect.TypeOf(s.Index(0).Interface())
+		}
+		if modelType.Kind() == reflect.Ptr {
+			modelType = modelType.Elem()
+		}
+		schema = CreateSchema(modelType)
+	}
+	conflict := upsert.Conflict
+	// Without the conflict columns, "do nothing" and "insert ignore" skip the rows which violate any unique key
+	anyConflict := upsert.Ignore && len(conflict) == 0 && driver != DriverOracle && driver != DriverMssql
+	if len(conflict) == 0 && !anyConflict {
+		for _, fdb := range schema.Keys {
+			conflict = append(conflict, fdb.Column)
+		}
+	}
+	if len(conflict) == 0 && driver != DriverMysql && !anyConflict {
+		return "", nil, fmt.Errorf("require the conflict columns or the primary key for table '%s'", table)
+	}
+	cols := make([]*FieldDB, 0)
+	for _, fdb := range schema.Columns {
+		if fdb.Insert || containsFold(conflict, fdb.Column) {
+			cols = append(cols, fdb)
+		}
+	}
+	iCols := make([]string, len(cols))
+	for k, fdb := range cols {
+		iCols[k] = fdb.Column
+	}
+	var sets []upsertSet
+	if !upsert.Ignore {
+		sets = buildUpsertSets(schema, conflict, upsert)
+	}
+	if buildParam == nil {
+		buildParam = GetBuildByDriver(driver)
+	}
+	boolSupport := driver == DriverPostgres
+	rows := make([][]string, slen)
+	args := make([]interface{}, 0)
+	i := 1
+	for j := 0; j < slen; j++ {
+		mv := reflect.Indirect(reflect.ValueOf(s.Index(j).Interface()))
+		var rowArgs []interface{}
+		rows[j], rowArgs, i = buildUpsertValues(mv, cols, buildParam, i, boolSupport, toArray)
+		args = append(args, rowArgs...)
+	}
+	switch driver {
+	case DriverPostgres, DriverSqlite3:
+		values := make([]string, slen)
+		for j, row := range rows {
+			values[j] = "(" + strings.Join(row, ",") + ")"
+		}
+		if anyConflict {
+			return fmt.Sprintf("insert into %s (%s) values %s on conflict do nothing", table, strings.Join(iCols, ","), strings.Join(values, ",")), args, nil
+		}
+		query := fmt.Sprintf("insert into %s as target (%s) values %s on conflict (%s) ", table, strings.Join(iCols, ","), strings.Join(values, ","), strings.Join(conflict, ","))
+		if len(sets) == 0 {
+			return query + "do nothing", args, nil
+		}
+		setColumns := make([]string, len(sets))
+		for k, set := range sets {
+			setColumns[k] = set.column + "=" + set.expression
+		}
+		query = query + "do update set " + strings.Join(setColumns, ",")
+		if len(upsert.Where) > 0 {
+			query = query + " where " + upsert.Where
+		}
+		return query, args, nil
+	case DriverMysql:
+		values := make([]string, slen)
+		for j, row := range rows {
+			values[j] = "(" + strings.Join(row, ",") + ")"
+		}
+		if len(sets) == 0 {
+			return fmt.Sprintf("insert ignore into %s(%s) values %s", table, strings.Join(iCols, ","), strings.Join(values, ",")), args, nil
+		}
+		where := toMysqlExpression(upsert.Where)
+		// My SQL assigns from left to right, so the columns of the condition are assigned last, to keep the condition the same for all columns.
+		if len(where) > 0 {
+			guards := targetRef.FindAllStringSubmatch(upsert.Where, -1)
+			sort.SliceStable(sets, func(a, b int) bool {
+				return !isReferred(guards, sets[a].column) && isReferred(guards, sets[b].column)
+			})
+		}
+		setColumns := make([]string, len(sets))
+		for k, set := range sets {
+			expression := toMysqlExpression(set.expression)
+			if len(where) > 0 {
+				expression = fmt.Sprintf("if(%s,%s,%s)", where, expression, set.column)
+			}
+			setColumns[k] = set.column + "=" + expression
+		}
+		return fmt.Sprintf("insert into %s(%s) values %s on duplicate key update %s", table, strings.Join(iCols, ","), strings.Join(values, ","), strings.Join(setColumns, ",")), args, nil
+	case DriverOracle, DriverMssql:
+		var source string
+		if driver == DriverOracle {
+			selects := make([]string, slen)
+			for j, row := range rows {
+				items := make([]string, len(row))
+				for k, v := range row {
+					items[k] = v + " " + iCols[k]
+				}
+				selects[j] = "select " + strings.Join(items, ", ") + " from dual"
+			}
+			source = "(" + strings.Join(selects, " union all ") + ") temp"
+		} else {
+			values := make([]string, slen)
+			for j, row := range rows {
+				values[j] = "(" + strings.Join(row, ", ") + ")"
+			}
+			source = "(values " + strings.Join(values, ", ") + ") as temp (" + strings.Join(iCols, ", ") + ")"
+		}
+		on := make([]string, len(conflict))
+		for k, col := range conflict {
+			on[k] = "target." + col + " = temp." + col
+		}
+		inColumns := make([]string, len(iCols))
+		for k, col := range iCols {
+			inColumns[k] = "temp." + col
+		}
+		alias := "target"
+		if driver == DriverMssql {
+			alias = "as target"
+		}
+		query := fmt.Sprintf("merge into %s %s using %s on (%s)", table, alias, source, strings.Join(on, " and "))
+		if len(sets) > 0 {
+			setColumns := make([]string, len(sets))
+			for k, set := range sets {
+				setColumns[k] = "target." + set.column + " = " + toMergeExpression(set.expression)
+			}
+			where := toMergeExpression(upsert.Where)
+			if driver == DriverOracle {
+				query = query + " when matched then update set " + strings.Join(setColumns, ", ")
+				if len(where) > 0 {
+					query = query + " where " + where
+				}
+			} else {
+				if len(where) > 0 {
+					query = query + " when matched and " + where + " then update set " + strings.Join(setColumns, ", ")
+				} else {
+					query = query + " when matched then update set " + strings.Join(setColumns, ", ")
+				}
+			}
+		}
+		query = query + fmt.Sprintf(" when not matched then insert (%s) values (%s)", strings.Join(iCols, ", "), strings.Join(inColumns, ", "))
+		if driver == DriverMssql {
+			query = query + ";"
+		}
+		return query, args, nil
+	default:
+		return "", nil, fmt.Errorf("unsupported db vendor")
+	}
+}
+
+type upsertSet struct {
+	column     string
+	expression string
+}
+
+// buildUpsertSets returns the columns to update and their expressions, by the syntax of Postgres.
+func buildUpsertSets(schema *Schema, conflict []string, upsert Upsert) []upsertSet {
+	sets := make([]upsertSet, 0)
+	added := make(map[string]bool)
+	for _, fdb := range schema.Columns {
+		col := fdb.Column
+		if containsFold(conflict, col) {
+			continue
+		}
+		if expression, ok := upsert.Expressions[col]; ok {
+			sets = append(sets, upsertSet{column: col, expression: expression})
+			added[col] = true
+			continue
+		}
+		if containsFold(upsert.Exclude, col) {
+			continue
+		}
+		if len(upsert.Include) > 0 {
+			if !containsFold(upsert.Include, col) {
+				continue
+			}
+		} else if fdb.Key || !fdb.Update {
+			continue
+		}
+		sets = append(sets, upsertSet{column: col, expression: "excluded." + col})
+		added[col] = true
+	}
+	others := make([]string, 0)
+	for col := range upsert.Expressions {
+		if !added[col] && !containsFold(conflict, col) {
+			others = append(others, col)
+		}
+	}
+	sort.Strings(others)
+	for _, col := range others {
+		sets = append(sets, upsertSet{column: col, expression: upsert.Expressions[col]})
+	}
+	return sets
+}
+func buildUpsertValues(mv reflect.Value, cols []*FieldDB, buildParam func(int) string, i int, boolSupport bool, toArray func(interface{}) interface {
+	driver.Valuer
+	sql.Scanner
+}) ([]string, []interface{}, int) {
+	values := make([]string, 0, len(cols))
+	args := make([]interface{}, 0)
+	for _, fdb := range cols {
+		f := mv.Field(fdb.Index)
+		fieldValue := f.Interface()
+		isNil := false
+		if f.Kind() == reflect.Ptr {
+			if f.IsNil() {
+				isNil = true
+			} else {
+				fieldValue = f.Elem().Interface()
+			}
+		}
+		if !isNil {
+			fieldValue, isNil = ToDBValueOrNull(fdb, fieldValue)
+		}
+		if isNil {
+			values = append(values, "null")
+			continue
+		}
+		if v, ok := GetDBValue(fieldValue, boolSupport, fdb.Scale); ok {
+			values = append(values, v)
+		} else if boolValue, ok := fieldValue.(bool); ok {
+			if boolValue && fdb.True != nil {
+				values = append(values, buildParam(i))
+				args = append(args, *fdb.True)
+				i = i + 1
+			} else if !boolValue && fdb.False != nil {
+				values = append(values, buildParam(i))
+				args = append(args, *fdb.False)
+				i = i + 1
+			} else if boolValue {
+				values = append(values, "'1'")
+			} else {
+				values = append(values, "'0'")
+			}
+		} else {
+			values = append(values, buildParam(i))
+			i = i + 1
+			if toArray != nil && reflect.TypeOf(fieldValue).Kind() == reflect.Slice {
+				args = append(args, toArray(fieldValue))
+			} else {
+				args = append(args, fieldValue)
+			}
+		}
+	}
+	return values, args, i
+}
+func toMysqlExpression(expression string) string {
+	expression = excludedRef.ReplaceAllString(expression, "values($1)")
+	return targetRef.ReplaceAllString(expression, "$1")
+}
+func toMergeExpression(expression string) string {
+	return excludedRef.ReplaceAllString(expression, "temp.$1")
+}
+func isReferred(refs [][]string, column string) bool {
+	for _, ref := range refs {
+		if strings.EqualFold(ref[1], column) {
+			return true
+		}
+	}
+	return false
+}
+func containsFold(columns []string, column string) bool {
+	for _, c := range columns {
+		if strings.EqualFold(c, column) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package sql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/core-go/sql/mock"
+)
+
+type testCounter struct {
+	Id    string `gorm:"column:id;primary_key"`
+	Name  string `gorm:"column:name"`
+	Count int64  `gorm:"column:count"`
+}
+
+var testCounters = []testCounter{{"1", "a", 2}, {"2", "b", 3}}
+
+func TestBuildToUpsertBatchByDialect(t *testing.T) {
+	upsert := Upsert{Expressions: map[string]string{"count": "target.count + excluded.count"}, Where: "excluded.name <> target.name"}
+	tests := []struct {
+		driver string
+		query  string
+	}{
+		{DriverPostgres, "insert into counters as target (id,name,count) values ($1,$2,2),($3,$4,3) on conflict (id) do update set name=excluded.name,count=target.count + excluded.count where excluded.name <> target.name"},
+		{DriverSqlite3, "insert into counters as target (id,name,count) values (?,?,2),(?,?,3) on conflict (id) do update set name=excluded.name,count=target.count + excluded.count where excluded.name <> target.name"},
+		{DriverMysql, "insert into counters(id,name,count) values (?,?,2),(?,?,3) on duplicate key update count=if(values(name) <> name,count + values(count),count),name=if(values(name) <> name,values(name),name)"},
+		{DriverOracle, "merge into counters target using (select :1 id, :2 name, 2 count from dual union all select :3 id, :4 name, 3 count from dual) temp on (target.id = temp.id) when matched then update set target.name = temp.name, target.count = target.count + temp.count where temp.name <> target.name when not matched then insert (id, name, count) values (temp.id, temp.name, temp.count)"},
+		{DriverMssql, "merge into counters as target using (values (@p1, @p2, 2), (@p3, @p4, 3)) as temp (id, name, count) on (target.id = temp.id) when matched and temp.name <> target.name then update set target.name = temp.name, target.count = target.count + temp.count when not matched then insert (id, name, count) values (temp.id, temp.name, temp.count);"},
+	}
+	for _, tt := range tests {
+		query, args, err := BuildToUpsertBatch("counters", testCounters, tt.driver, upsert)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.driver, err)
+		}
+		if query != tt.query {
+			t.Errorf("%s: expected\n%s\ngot\n%s", tt.driver, tt.query, query)
+		}
+		if len(args) != 4 || args[0] != "1" || args[3] != "b" {
+			t.Errorf("%s: unexpected args %v", tt.driver, args)
+		}
+	}
+}
+
+func TestBuildToUpsertIncludeAndExclude(t *testing.T) {
+	query, _, err := BuildToUpsert("counters", testCounters[0], DriverPostgres, Upsert{Exclude: []string{"name"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "insert into counters as target (id,name,count) values ($1,$2,2) on conflict (id) do update set count=excluded.count"; query != expected {
+		t.Errorf("expected %s, got %s", expected, query)
+	}
+	query, _, _ = BuildToUpsert("counters", &testCounters[0], DriverPostgres, Upsert{Conflict: []string{"name"}, Include: []string{"count"}})
+	if expected := "insert into counters as target (id,name,count) values ($1,$2,2) on conflict (name) do update set count=excluded.count"; query != expected {
+		t.Errorf("expected %s, got %s", expected, query)
+	}
+	if _, _, err := BuildToUpsertBatch("counters", testCounters, "db2", Upsert{}); err == nil {
+		t.Error("expected the error of the unsupported driver")
+	}
+}
+
+func TestInsertBatchIgnoreByDialect(t *testing.T) {
+	tests := []struct {
+		dialect string
+		query   string
+	}{
+		{"postgres", "insert into counters (id,name,count) values ($1,$2,2),($3,$4,3) on conflict do nothing"},
+		{"sqlite3", "insert into counters (id,name,count) values (?,?,2),(?,?,3) on conflict do nothing"},
+		{"mysql", "insert ignore into counters(id,name,count) values (?,?,2),(?,?,3)"},
+		{"oracle", "merge into counters target using (select :1 id, :2 name, 2 count from dual union all select :3 id, :4 name, 3 count from dual) temp on (target.id = temp.id) when not matched then insert (id, name, count) values (temp.id, temp.name, temp.count)"},
+		{"mssql", "merge into counters as target using (values (@p1, @p2, 2), (@p3, @p4, 3)) as temp (id, name, count) on (target.id = temp.id) when not matched then insert (id, name, count) values (temp.id, temp.name, temp.count);"},
+	}
+	for _, tt := range tests {
+		db, rec := mock.New(tt.dialect)
+		rec.ExpectRegexp("counters").WillReturnResult(0, 1)
+		inserted, skipped, err := InsertBatchIgnore(context.Background(), db, "counters", testCounters)
+		if err != nil || inserted != 1 || skipped != 1 {
+			t.Fatalf("%s: expected 1 inserted and 1 skipped row, got %d %d %v", tt.dialect, inserted, skipped, err)
+		}
+		rec.AssertStatement(t, tt.query, "1", "a", "2", "b")
+	}
+}
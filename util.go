@@ -29,6 +29,15 @@ type FieldDB struct {
 	Scale  int8
 	True   *string
 	False  *string
+
+	// Serializer is "json" for the fields stored as JSON, by the gorm tag serializer:json
+	Serializer string
+	// Encrypt is "true" or "deterministic" for the fields encrypted by the cipher, by the tag encrypt
+	Encrypt string
+	// Converter is the registered converter of the field, by the tag convert or by the type of the field
+	Converter *Converter
+	// Type is the type of the field, to convert the values of the patch map before Converter
+	Type reflect.Type
 }
 type Schema struct {
 	SKeys    []string
@@ -52,7 +61,12 @@ func BuildQueryBySchema(table string, schema *Schema) string {
 	}
 	return "select " + strings.Join(columns, ",") + " from " + table + " "
 }
-func CreateSchema(modelType reflect.Type) *Schema {
+// CreateSchema creates the schema of the model. If the driver is passed, the converters of the driver are used.
+func CreateSchema(modelType reflect.Type, options ...string) *Schema {
+	var driver string
+	if len(options) > 0 {
+		driver = options[0]
+	}
 	m := modelType
 	if m.Kind() == reflect.Ptr {
 		m = m.Elem()
@@ -114,6 +128,14 @@ func CreateSchema(modelType reflect.Type) *Schema {
 									f.False = &fTag
 								}
 							}
+							if serializer, ok := FindTag(tag, "serializer"); ok {
+								f.Serializer = serializer
+							}
+							if encrypt, ok := field.Tag.Lookup("encrypt"); ok {
+								f.Encrypt = encrypt
+							}
+							f.Converter = FindConverter(driver, field)
+							f.Type = field.Type
 							columns = append(columns, f)
 							schema[col] = f
 						}
@@ -148,6 +170,9 @@ func QuoteColumnName(str string) string {
 	return str
 }
 func GetDBValue(v interface{}, boolSupport bool, scale int8) (string, bool) {
+	if isValuer(v) {
+		return "", false
+	}
 	switch v.(type) {
 	case string:
 		s0 := v.(string)
@@ -50,7 +50,11 @@ func Init(modelType reflect.Type, db *sql.DB) (map[string]int, *Schema, map[stri
 	if err != nil {
 		return nil, nil, nil, nil, nil, "", nil, "", err
 	}
-	schema := CreateSchema(modelType)
+	var driver string
+	if db != nil {
+		driver = GetDriver(db)
+	}
+	schema := CreateSchema(modelType, driver)
 	fields := BuildFieldsBySchema(schema)
 	jsonColumnMap := MakeJsonColumnMap(modelType)
 	jm := GetWritableColumns(schema.Fields, jsonColumnMap)
@@ -58,7 +62,6 @@ func Init(modelType reflect.Type, db *sql.DB) (map[string]int, *Schema, map[stri
 	if db == nil {
 		return fieldsIndex, schema, jm, keys, arr, fields, nil, "", nil
 	}
-	driver := GetDriver(db)
 	buildParam := GetBuild(db)
 	return fieldsIndex, schema, jm, keys, arr, fields, buildParam, driver, nil
 }
@@ -261,9 +264,14 @@ func BuildToPatchWithVersion(table string, model map[string]interface{}, keyColu
 	i := 1
 	for col, v := range model {
 		if !Contains(keyColumns, col) && col != version {
-			if v == nil {
+			if x, null := GetNullValue(v); null {
 				values = append(values, col+"=null")
 			} else {
+				if fdb, ok := schema[col]; ok && hasDBValue(fdb) {
+					v = ToPatchValue(fdb, v)
+				} else {
+					v = x
+				}
 				v2, ok2 := GetDBValue(v, false, -1)
 				if ok2 {
 					values = append(values, col+"="+v2)
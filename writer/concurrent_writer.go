@@ -0,0 +1,219 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/core-go/sql/batch"
+)
+
+var ErrClosed = errors.New("writer is closed")
+
+// FlushResult is the outcome of a flush of ConcurrentWriter.
+type FlushResult struct {
+	Size     int
+	Duration time.Duration
+	Err      error
+}
+
+type flushRequest struct {
+	ctx  context.Context
+	done chan error
+}
+
+// ConcurrentWriter is a stream writer which can be shared between goroutines.
+// A background goroutine flushes the batch when it has batchSize rows, or every interval, so a row waits for interval at most.
+// The rows are buffered in a channel of bufferSize, so Write blocks when the buffer is full, until the background goroutine takes the rows.
+// If a batch fails, its rows are kept and written again by the next flush, every interval, or every second if interval is 0. Meanwhile, Write blocks when the buffer is full.
+type ConcurrentWriter[T any] struct {
+	write     func(ctx context.Context, models []T) error
+	batchSize int
+	interval  time.Duration
+	onFlush   func(FlushResult)
+	ch        chan T
+	flushes   chan flushRequest
+	done      chan struct{}
+	// mu is held by Write to send to ch, and by Close to close ch
+	mu        sync.RWMutex
+	closing   chan struct{}
+	closeOnce sync.Once
+	closeErr  error
+	// FlushTimeout bounds the write of each batch, which is not canceled with the context of Flush or Close. It is 30 seconds by default
+	FlushTimeout time.Duration
+}
+
+// NewConcurrentWriter creates a ConcurrentWriter, which flushes by write, such as BatchWriter.Write. If interval is 0, the batch is flushed by the size only.
+// options[0] is called after every flush, with the number of rows and the error.
+func NewConcurrentWriter[T any](write func(context.Context, []T) error, batchSize int, bufferSize int, interval time.Duration, options ...func(FlushResult)) *ConcurrentWriter[T] {
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+	if bufferSize < 0 {
+		bufferSize = 0
+	}
+	var onFlush func(FlushResult)
+	if len(options) > 0 {
+		onFlush = options[0]
+	}
+	w := &ConcurrentWriter[T]{write: write, batchSize: batchSize, interval: interval, onFlush: onFlush,
+		ch: make(chan T, bufferSize), flushes: make(chan flushRequest), done: make(chan struct{}), closing: make(chan struct{}), FlushTimeout: 30 * time.Second}
+	go w.run()
+	return w
+}
+func NewConcurrentStreamWriter[T any](db *sql.DB, tableName string, batchSize int, bufferSize int, interval time.Duration, options ...func(FlushResult)) *ConcurrentWriter[T] {
+	w := batch.NewBatchWriter[T](db, tableName)
+	return NewConcurrentWriter[T](w.Write, batchSize, bufferSize, interval, options...)
+}
+func NewConcurrentStreamInserter[T any](db *sql.DB, tableName string, batchSize int, bufferSize int, interval time.Duration, options ...func(FlushResult)) *ConcurrentWriter[T] {
+	w := batch.NewBatchInserter[T](db, tableName)
+	return NewConcurrentWriter[T](w.Write, batchSize, bufferSize, interval, options...)
+}
+func NewConcurrentStreamUpdater[T any](db *sql.DB, tableName string, batchSize int, bufferSize int, interval time.Duration, options ...func(FlushResult)) *ConcurrentWriter[T] {
+	w := batch.NewBatchUpdater[T](db, tableName)
+	return NewConcurrentWriter[T](w.Write, batchSize, bufferSize, interval, options...)
+}
+
+// Write adds the model to the buffer. It blocks while the buffer is full, until ctx is done or the writer is closed.
+func (w *ConcurrentWriter[T]) Write(ctx context.Context, model T) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	select {
+	case <-w.closing:
+		return ErrClosed
+	default:
+	}
+	select {
+	case w.ch <- model:
+		return nil
+	case <-w.closing:
+		return ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Flush writes the buffered rows, and returns the error of the flush. If ctx is done first, the rows are still written in the background.
+func (w *ConcurrentWriter[T]) Flush(ctx context.Context) error {
+	req := flushRequest{ctx: ctx, done: make(chan error, 1)}
+	select {
+	case w.flushes <- req:
+	case <-w.done:
+		return ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case err := <-req.done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops accepting rows, flushes the buffered rows and stops the background goroutine. It waits until the rows are written, or ctx is done.
+// If ctx is done first, the buffered rows are still written in the background.
+func (w *ConcurrentWriter[T]) Close(ctx context.Context) error {
+	w.closeOnce.Do(func() {
+		close(w.closing)
+		// the blocked writers return ErrClosed, then ch is closed when they release mu
+		go func() {
+			w.mu.Lock()
+			close(w.ch)
+			w.mu.Unlock()
+		}()
+	})
+	select {
+	case <-w.done:
+		return w.closeErr
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (w *ConcurrentWriter[T]) run() {
+	defer close(w.done)
+	var tick <-chan time.Time
+	retryInterval := w.interval
+	if w.interval > 0 {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	} else {
+		retryInterval = time.Second
+	}
+	var retry <-chan time.Time
+	models := make([]T, 0, w.batchSize)
+	var err error
+	for {
+		in := w.ch
+		if len(models) >= w.batchSize && retry != nil {
+			// the failed rows are not written yet, so the writers wait, unless the writer is closing
+			select {
+			case <-w.closing:
+			default:
+				in = nil
+			}
+		}
+		select {
+		case model, ok := <-in:
+			if !ok {
+				models, w.closeErr = w.flushAll(models)
+				return
+			}
+			models = append(models, model)
+			if len(models) < w.batchSize || retry != nil {
+				continue
+			}
+		case <-tick:
+		case <-retry:
+		case req := <-w.flushes:
+			for len(w.ch) > 0 {
+				models = append(models, <-w.ch)
+			}
+			models, err = w.flushAll(models)
+			req.done <- err
+			retry = nil
+			if err != nil {
+				retry = time.After(retryInterval)
+			}
+			continue
+		}
+		models, err = w.flushAll(models)
+		retry = nil
+		if err != nil {
+			retry = time.After(retryInterval)
+		}
+	}
+}
+
+// flushAll writes the models by batchSize. If a batch fails, it returns the rows which are not written.
+func (w *ConcurrentWriter[T]) flushAll(models []T) ([]T, error) {
+	for len(models) > 0 {
+		n := len(models)
+		if n > w.batchSize {
+			n = w.batchSize
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), w.FlushTimeout)
+		err := w.flush(ctx, models[:n])
+		cancel()
+		if err != nil {
+			return models, err
+		}
+		models = models[n:]
+	}
+	return make([]T, 0, w.batchSize), nil
+}
+func (w *ConcurrentWriter[T]) flush(ctx context.Context, models []T) error {
+	if len(models) == 0 {
+		return nil
+	}
+	start := time.Now()
+	err := w.write(ctx, models)
+	if w.onFlush != nil {
+		w.onFlush(FlushResult{Size: len(models), Duration: time.Since(start), Err: err})
+	}
+	return err
+}
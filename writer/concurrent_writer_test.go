@@ -0,0 +1,167 @@
+package sql
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/core-go/sql/mock"
+)
+
+type testUser struct {
+	Id   string `gorm:"column:id;primary_key"`
+	Name string `gorm:"column:name"`
+}
+
+// testSink records the written batches. It fails while fails > 0, and waits for release if it is not nil.
+type testSink struct {
+	mu      sync.Mutex
+	rows    []int
+	fails   int
+	release chan struct{}
+}
+
+func (s *testSink) write(ctx context.Context, models []int) error {
+	if s.release != nil {
+		<-s.release
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fails > 0 {
+		s.fails--
+		return errors.New("write failed")
+	}
+	s.rows = append(s.rows, models...)
+	return nil
+}
+func (s *testSink) written() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]int(nil), s.rows...)
+}
+
+func TestConcurrentWriterFlushIsNotCanceledByCaller(t *testing.T) {
+	sink := &testSink{release: make(chan struct{})}
+	w := NewConcurrentWriter[int](sink.write, 10, 10, 0)
+	for i := 1; i <= 3; i++ {
+		if err := w.Write(context.Background(), i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := w.Flush(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected DeadlineExceeded, got %v", err)
+	}
+	close(sink.release)
+	if err := w.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if rows := sink.written(); len(rows) != 3 {
+		t.Fatalf("expected 3 rows, got %v", rows)
+	}
+}
+
+func TestConcurrentWriterKeepsFailedRows(t *testing.T) {
+	sink := &testSink{fails: 2}
+	var mu sync.Mutex
+	var results []FlushResult
+	w := NewConcurrentWriter[int](sink.write, 2, 10, 10*time.Millisecond, func(r FlushResult) {
+		mu.Lock()
+		results = append(results, r)
+		mu.Unlock()
+	})
+	w.Write(context.Background(), 1)
+	w.Write(context.Background(), 2)
+	if err := w.Flush(context.Background()); err == nil {
+		t.Fatal("expected the error of the failed batch")
+	}
+	deadline := time.Now().Add(time.Second)
+	for len(sink.written()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if rows := sink.written(); len(rows) != 2 || rows[0] != 1 || rows[1] != 2 {
+		t.Fatalf("expected the failed rows to be written again, got %v", rows)
+	}
+	w.Close(context.Background())
+	mu.Lock()
+	defer mu.Unlock()
+	if len(results) < 3 || results[0].Err == nil || results[len(results)-1].Err != nil {
+		t.Fatalf("unexpected flush results %v", results)
+	}
+}
+
+func TestConcurrentWriterCloseDrainsAndRejects(t *testing.T) {
+	sink := &testSink{}
+	w := NewConcurrentWriter[int](sink.write, 2, 10, 0)
+	for i := 1; i <= 5; i++ {
+		w.Write(context.Background(), i)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	w.Close(ctx)
+	<-w.done
+	if rows := sink.written(); len(rows) != 5 {
+		t.Fatalf("expected 5 rows, got %v", rows)
+	}
+	if err := w.Write(context.Background(), 6); err != ErrClosed {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+	if err := w.Flush(context.Background()); err != ErrClosed {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+}
+
+func TestConcurrentWriterCloseUnblocksWriters(t *testing.T) {
+	sink := &testSink{release: make(chan struct{})}
+	w := NewConcurrentWriter[int](sink.write, 1, 1, 0)
+	w.Write(context.Background(), 1)
+	w.Write(context.Background(), 2)
+	errc := make(chan error)
+	go func() {
+		errc <- w.Write(context.Background(), 3)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := w.Close(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected DeadlineExceeded, got %v", err)
+	}
+	if err := <-errc; err != ErrClosed {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+	close(sink.release)
+	<-w.done
+	if rows := sink.written(); len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %v", rows)
+	}
+}
+
+func TestConcurrentStreamInserter(t *testing.T) {
+	db, rec := mock.New("postgres")
+	w := NewConcurrentStreamInserter[testUser](db, "users", 2, 10, 0)
+	w.Write(context.Background(), testUser{Id: "1", Name: "a"})
+	w.Write(context.Background(), testUser{Id: "2", Name: "b"})
+	w.Write(context.Background(), testUser{Id: "3", Name: "c"})
+	if err := w.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var inserts []mock.Statement
+	for _, s := range rec.Statements() {
+		if strings.HasPrefix(s.Query, "insert") {
+			inserts = append(inserts, s)
+		}
+	}
+	if len(inserts) != 2 || len(inserts[0].Args) != 4 || len(inserts[1].Args) != 2 {
+		t.Fatalf("expected the batches of 2 and 1 rows, got %v", inserts)
+	}
+	if !strings.Contains(inserts[0].Query, "$4") {
+		t.Fatalf("expected the placeholders of postgres, got %s", inserts[0].Query)
+	}
+}
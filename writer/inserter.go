@@ -57,7 +57,7 @@ func NewSqlInserter[T any](db *sql.DB, tableName string, mp func(T), toArray fun
 	}
 	driver := q.GetDriver(db)
 	boolSupport := driver == q.DriverPostgres
-	schema := q.CreateSchema(modelType)
+	schema := q.CreateSchema(modelType, driver)
 	return &Inserter[T]{db: db, BoolSupport: boolSupport, VersionIndex: -1, schema: schema, tableName: tableName, BuildParam: buildParam, Map: mp, ToArray: toArray}
 }
 
@@ -0,0 +1,211 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	q "github.com/core-go/sql"
+	"github.com/core-go/sql/batch"
+)
+
+// PipelineResult is the aggregated outcome of the flushes of all partitions of PipelineWriter.
+type PipelineResult struct {
+	Flushes int64
+	Rows    int64
+	Failed  int64
+	Errors  []PartitionError
+}
+
+// PartitionError is the error of a flush of a partition.
+type PartitionError struct {
+	Partition int
+	Size      int
+	Err       error
+}
+
+func (e PartitionError) Error() string {
+	return fmt.Sprintf("partition %d: %s", e.Partition, e.Err.Error())
+}
+func (e PartitionError) Unwrap() error {
+	return e.Err
+}
+
+// PipelineError is returned by Flush and Close if some partitions fail.
+type PipelineError struct {
+	Errors []PartitionError
+}
+
+func (e PipelineError) Error() string {
+	s := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		s[i] = err.Error()
+	}
+	return strings.Join(s, "; ")
+}
+
+// PipelineWriter partitions the rows by the hash of the primary key across the workers, so the rows of the same key are written in order.
+// Each worker is a ConcurrentWriter, which flushes its own batch in its own transaction.
+type PipelineWriter[T any] struct {
+	partitions []*ConcurrentWriter[T]
+	// Key returns the key to partition the rows, which is the primary key by default
+	Key    func(T) string
+	mu     sync.Mutex
+	result PipelineResult
+	// MaxErrors is the maximum number of the errors kept in the result
+	MaxErrors int
+}
+
+// NewPipelineWriter creates a PipelineWriter of the workers, which flush by write, such as BatchWriter.Write.
+// maxInFlight is the maximum number of the rows buffered and being written by all workers, approximately. It is at least batchSize for each worker.
+// options[0] is called after every flush of every worker, with the index of the worker.
+func NewPipelineWriter[T any](write func(context.Context, []T) error, workers int, batchSize int, maxInFlight int, interval time.Duration, options ...func(int, FlushResult)) *PipelineWriter[T] {
+	if workers <= 0 {
+		workers = 1
+	}
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+	bufferSize := maxInFlight/workers - batchSize
+	if bufferSize < 0 {
+		bufferSize = 0
+	}
+	var onFlush func(int, FlushResult)
+	if len(options) > 0 {
+		onFlush = options[0]
+	}
+	w := &PipelineWriter[T]{Key: primaryKey[T](), MaxErrors: 100}
+	w.partitions = make([]*ConcurrentWriter[T], workers)
+	for i := 0; i < workers; i++ {
+		partition := i
+		w.partitions[i] = NewConcurrentWriter[T](write, batchSize, bufferSize, interval, func(r FlushResult) {
+			w.collect(partition, r)
+			if onFlush != nil {
+				onFlush(partition, r)
+			}
+		})
+	}
+	return w
+}
+func NewPipelineStreamWriter[T any](db *sql.DB, tableName string, workers int, batchSize int, maxInFlight int, interval time.Duration, options ...func(int, FlushResult)) *PipelineWriter[T] {
+	w := batch.NewBatchWriter[T](db, tableName)
+	return NewPipelineWriter[T](w.Write, workers, batchSize, maxInFlight, interval, options...)
+}
+func NewPipelineStreamInserter[T any](db *sql.DB, tableName string, workers int, batchSize int, maxInFlight int, interval time.Duration, options ...func(int, FlushResult)) *PipelineWriter[T] {
+	w := batch.NewBatchInserter[T](db, tableName)
+	return NewPipelineWriter[T](w.Write, workers, batchSize, maxInFlight, interval, options...)
+}
+func NewPipelineStreamUpdater[T any](db *sql.DB, tableName string, workers int, batchSize int, maxInFlight int, interval time.Duration, options ...func(int, FlushResult)) *PipelineWriter[T] {
+	w := batch.NewBatchUpdater[T](db, tableName)
+	return NewPipelineWriter[T](w.Write, workers, batchSize, maxInFlight, interval, options...)
+}
+
+// Write sends the model to the worker of its key. It blocks while the buffer of the worker is full, until ctx is done.
+func (w *PipelineWriter[T]) Write(ctx context.Context, model T) error {
+	return w.partitions[w.partition(model)].Write(ctx, model)
+}
+
+// Flush flushes all workers in parallel, and returns PipelineError if some workers fail.
+func (w *PipelineWriter[T]) Flush(ctx context.Context) error {
+	return w.each(func(p *ConcurrentWriter[T]) error {
+		return p.Flush(ctx)
+	})
+}
+
+// Close closes all workers in parallel, after the buffered rows are written.
+func (w *PipelineWriter[T]) Close(ctx context.Context) error {
+	return w.each(func(p *ConcurrentWriter[T]) error {
+		return p.Close(ctx)
+	})
+}
+
+// Result returns the aggregated result of the flushes since the writer is created.
+func (w *PipelineWriter[T]) Result() PipelineResult {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	res := w.result
+	res.Errors = append([]PartitionError(nil), w.result.Errors...)
+	return res
+}
+
+func (w *PipelineWriter[T]) partition(model T) int {
+	if len(w.partitions) == 1 || w.Key == nil {
+		return 0
+	}
+	h := fnv.New32a()
+	h.Write([]byte(w.Key(model)))
+	return int(h.Sum32() % uint32(len(w.partitions)))
+}
+func (w *PipelineWriter[T]) collect(partition int, r FlushResult) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.result.Flushes++
+	if r.Err != nil {
+		w.result.Failed += int64(r.Size)
+		if len(w.result.Errors) < w.MaxErrors {
+			w.result.Errors = append(w.result.Errors, PartitionError{Partition: partition, Size: r.Size, Err: r.Err})
+		}
+	} else {
+		w.result.Rows += int64(r.Size)
+	}
+}
+func (w *PipelineWriter[T]) each(f func(*ConcurrentWriter[T]) error) error {
+	errs := make([]error, len(w.partitions))
+	var wg sync.WaitGroup
+	for i, p := range w.partitions {
+		wg.Add(1)
+		go func(i int, p *ConcurrentWriter[T]) {
+			defer wg.Done()
+			errs[i] = f(p)
+		}(i, p)
+	}
+	wg.Wait()
+	var res []PartitionError
+	for i, err := range errs {
+		if err != nil {
+			res = append(res, PartitionError{Partition: i, Err: err})
+		}
+	}
+	if len(res) > 0 {
+		return PipelineError{Errors: res}
+	}
+	return nil
+}
+
+// primaryKey returns the function to get the primary key of T, or nil if T has no primary key, then all rows go to the first worker.
+func primaryKey[T any]() func(T) string {
+	var t T
+	modelType := reflect.TypeOf(t)
+	if modelType == nil {
+		return nil
+	}
+	if modelType.Kind() == reflect.Ptr {
+		modelType = modelType.Elem()
+	}
+	if modelType.Kind() != reflect.Struct {
+		return nil
+	}
+	schema := q.CreateSchema(modelType)
+	if len(schema.Keys) == 0 {
+		return nil
+	}
+	return func(model T) string {
+		v := reflect.Indirect(reflect.ValueOf(model))
+		if !v.IsValid() {
+			return ""
+		}
+		var sb strings.Builder
+		for i, k := range schema.Keys {
+			if i > 0 {
+				sb.WriteByte(0)
+			}
+			fmt.Fprint(&sb, v.Field(k.Index).Interface())
+		}
+		return sb.String()
+	}
+}
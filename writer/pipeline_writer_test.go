@@ -0,0 +1,105 @@
+package sql
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/core-go/sql/mock"
+)
+
+func TestPipelineWriterKeepsOrderOfKey(t *testing.T) {
+	var mu sync.Mutex
+	written := make(map[string][]int)
+	write := func(ctx context.Context, users []testUser) error {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, u := range users {
+			n, _ := strconv.Atoi(u.Name)
+			written[u.Id] = append(written[u.Id], n)
+		}
+		return nil
+	}
+	w := NewPipelineWriter[testUser](write, 4, 3, 100, 0)
+	for i := 0; i < 100; i++ {
+		if err := w.Write(context.Background(), testUser{Id: strconv.Itoa(i % 10), Name: strconv.Itoa(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(written) != 10 {
+		t.Fatalf("expected 10 keys, got %d", len(written))
+	}
+	for id, names := range written {
+		if len(names) != 10 {
+			t.Fatalf("expected 10 rows of key %s, got %v", id, names)
+		}
+		for i := 1; i < len(names); i++ {
+			if names[i] < names[i-1] {
+				t.Fatalf("expected the rows of key %s in order, got %v", id, names)
+			}
+		}
+	}
+	if res := w.Result(); res.Rows != 100 || res.Failed != 0 {
+		t.Errorf("expected 100 rows, got %v", res)
+	}
+}
+
+func TestPipelineWriterReportsPartitionErrors(t *testing.T) {
+	failed := errors.New("write failed")
+	var mu sync.Mutex
+	var partitions []int
+	w := NewPipelineWriter[testUser](func(ctx context.Context, users []testUser) error {
+		return failed
+	}, 2, 10, 100, 0, func(partition int, r FlushResult) {
+		mu.Lock()
+		partitions = append(partitions, partition)
+		mu.Unlock()
+	})
+	w.Write(context.Background(), testUser{Id: "1"})
+	err := w.Flush(context.Background())
+	var pipelineErr PipelineError
+	if !errors.As(err, &pipelineErr) || len(pipelineErr.Errors) != 1 || !errors.Is(pipelineErr.Errors[0], failed) {
+		t.Fatalf("expected the error of 1 partition, got %v", err)
+	}
+	res := w.Result()
+	if res.Failed != 1 || len(res.Errors) != 1 || res.Errors[0].Partition != pipelineErr.Errors[0].Partition {
+		t.Fatalf("expected 1 failed row, got %v", res)
+	}
+	mu.Lock()
+	if len(partitions) != 1 || partitions[0] != res.Errors[0].Partition {
+		t.Errorf("expected the callback of the partition, got %v", partitions)
+	}
+	mu.Unlock()
+	if err := w.Close(context.Background()); err == nil {
+		t.Error("expected the error of the rows which cannot be written at close")
+	}
+}
+
+func TestPipelineStreamInserter(t *testing.T) {
+	db, rec := mock.New("postgres")
+	w := NewPipelineStreamInserter[testUser](db, "users", 2, 10, 100, 0)
+	for i := 0; i < 6; i++ {
+		w.Write(context.Background(), testUser{Id: strconv.Itoa(i), Name: "n"})
+	}
+	if err := w.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	rows := 0
+	for _, s := range rec.Statements() {
+		if strings.HasPrefix(s.Query, "insert into users") {
+			if !s.Tx {
+				t.Errorf("expected the insert in a transaction, got %v", s)
+			}
+			rows += len(s.Args) / 2
+		}
+	}
+	if rows != 6 {
+		t.Fatalf("expected 6 rows to be inserted, got %d", rows)
+	}
+}
@@ -22,6 +22,11 @@ type StreamInserter[T any] struct {
 		driver.Valuer
 		sql.Scanner
 	}
+	// SkipDuplicates skips the rows which violate the primary key or a unique key, instead of failing the whole batch
+	SkipDuplicates bool
+	// Inserted and Skipped are the numbers of the rows inserted and skipped by Flush
+	Inserted int64
+	Skipped  int64
 }
 
 func NewStreamInserter[T any](db *sql.DB, tableName string, batchSize int, options ...func(T)) *StreamInserter[T] {
@@ -59,7 +64,7 @@ func NewSqlStreamInserter[T any](db *sql.DB, tableName string, batchSize int,
 	if modelType.Kind() == reflect.Ptr {
 		modelType = modelType.Elem()
 	}
-	schema := q.CreateSchema(modelType)
+	schema := q.CreateSchema(modelType, driver)
 	return &StreamInserter[T]{db: db, Driver: driver, schema: schema, tableName: tableName, batchSize: batchSize, BuildParam: buildParam, Map: mp, ToArray: toArray}
 }
 
@@ -75,7 +80,14 @@ func (w *StreamInserter[T]) Write(ctx context.Context, model T) error {
 }
 
 func (w *StreamInserter[T]) Flush(ctx context.Context) error {
-	query, args, err := q.BuildToInsertBatchWithSchema(w.tableName, w.batch, w.Driver, w.ToArray, w.BuildParam, w.schema)
+	var query string
+	var args []interface{}
+	var err error
+	if w.SkipDuplicates {
+		query, args, err = q.BuildToInsertIgnoreBatch(w.tableName, w.batch, w.Driver, w.ToArray, w.BuildParam, w.schema)
+	} else {
+		query, args, err = q.BuildToInsertBatchWithSchema(w.tableName, w.batch, w.Driver, w.ToArray, w.BuildParam, w.schema)
+	}
 	if err != nil {
 		return err
 	}
@@ -89,9 +101,23 @@ func (w *StreamInserter[T]) Flush(ctx context.Context) error {
 	}()
 	defer tx.Rollback()
 
-	_, err = tx.ExecContext(ctx, query, args...)
+	res, err := tx.ExecContext(ctx, query, args...)
 	if err != nil {
 		return err
 	}
-	return tx.Commit()
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	l := int64(len(w.batch))
+	if !w.SkipDuplicates {
+		w.Inserted += l
+		return nil
+	}
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	w.Inserted += inserted
+	w.Skipped += l - inserted
+	return nil
 }
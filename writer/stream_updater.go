@@ -57,7 +57,7 @@ func NewSqlStreamUpdater[T any](db *sql.DB, tableName string, modelType reflect.
 	}
 	driver := q.GetDriver(db)
 	boolSupport := driver == q.DriverPostgres
-	schema := q.CreateSchema(modelType)
+	schema := q.CreateSchema(modelType, driver)
 	if len(schema.Keys) <= 0 {
 		panic(fmt.Sprintf("require primary key for table '%s'", tableName))
 	}
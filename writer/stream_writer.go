@@ -61,7 +61,7 @@ func NewSqlStreamWriter[T any](db *sql.DB, tableName string, batchSize int,
 	if modelType.Kind() == reflect.Ptr {
 		modelType = modelType.Elem()
 	}
-	schema := q.CreateSchema(modelType)
+	schema := q.CreateSchema(modelType, driver)
 	if len(schema.Keys) <= 0 {
 		panic(fmt.Sprintf("require primary key for table '%s'", tableName))
 	}
@@ -41,7 +41,7 @@ func NewWriterWithMap[T any](db *sql.DB, tableName string, mp func(T), toArray f
 	if modelType.Kind() == reflect.Ptr {
 		modelType = modelType.Elem()
 	}
-	schema := q.CreateSchema(modelType)
+	schema := q.CreateSchema(modelType, driver)
 	if len(schema.Keys) <= 0 {
 		panic(fmt.Sprintf("require primary key for table '%s'", tableName))
 	}